@@ -0,0 +1,71 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// accessReviewTop is how many owners are listed for every path set.
+const accessReviewTop = 3
+
+// accessReviewTable lists the top owners of every path set from the config
+// file, one row per owner, for access recertification tools.
+func accessReviewTable(report Report, config Config) table {
+	t := table{Headers: []string{"Resource", "Owner", "Email", "Lines", "Share%"}}
+
+	for _, set := range config.File.AccessReview {
+		lines := make(map[string]int)
+		total := 0
+		for _, file := range report.Files {
+			if !matchesAnyPattern(file.Path, set.Paths) {
+				continue
+			}
+			for actor, n := range file.Lines {
+				lines[actor] += n
+				total += n
+			}
+		}
+
+		for _, owner := range topNames(lines, accessReviewTop) {
+			email := primaryEmail(report.Actors[owner])
+			share := percent(lines[owner], total)
+			t.Rows = append(t.Rows, []string{set.Name, owner, email, strconv.Itoa(lines[owner]), formatValue(share)})
+			t.Items = append(t.Items, record{
+				{Key: "resource", Value: set.Name},
+				{Key: "owner", Value: owner},
+				{Key: "email", Value: email},
+				{Key: "lines", Value: lines[owner]},
+				{Key: "share", Value: share},
+			})
+		}
+	}
+	return t
+}
+
+func matchesAnyPattern(file string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := doublestar.Match(pattern, file); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func writeAccessReview(report Report, config Config) {
+	var buf bytes.Buffer
+	t := redactTable(accessReviewTable(report, config), config.File.Redaction["access-review"])
+	err := writeCSV(&buf, t)
+	if err == nil {
+		err = writeReportFile(config.AccessReview, buf.Bytes(), config)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Writing error: %s", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build !solution
+
+package main
+
+import "time"
+
+var activityColumns = []column{
+	{Header: "FirstCommit", Key: "first_commit", Value: func(a ActorStats) any {
+		first, _ := activitySpan(a.authorTimes)
+		return formatDate(first)
+	}},
+	{Header: "LastCommit", Key: "last_commit", Value: func(a ActorStats) any {
+		_, last := activitySpan(a.authorTimes)
+		return formatDate(last)
+	}},
+}
+
+// activitySpan returns the earliest and latest author-time of the actor's
+// surviving lines.
+func activitySpan(authorTimes map[int64]int) (int64, int64) {
+	var first, last int64
+	for t := range authorTimes {
+		if first == 0 || t < first {
+			first = t
+		}
+		if t > last {
+			last = t
+		}
+	}
+	return first, last
+}
+
+func formatDate(timestamp int64) string {
+	if timestamp == 0 {
+		return ""
+	}
+	return time.Unix(timestamp, 0).UTC().Format("2006-01-02")
+}
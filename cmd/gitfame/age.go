@@ -0,0 +1,61 @@
+//go:build !solution
+
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+const secondsPerDay = 24 * 60 * 60
+
+var ageColumns = []column{
+	{Header: "AvgAge", Key: "avg_age_days", Numeric: true, Value: func(a ActorStats) any { return a.avgAge }},
+	{Header: "MedianAge", Key: "median_age_days", Numeric: true, Value: func(a ActorStats) any { return a.medianAge }},
+}
+
+// computeAge sets the average and median age in days of every actor's
+// surviving lines, measured from their author-time to the revision date.
+func computeAge(stats map[string]ActorStats, config Config) {
+	if !config.Age {
+		return
+	}
+
+	revisionTime := commitTime(config, config.Revision)
+	for actor, s := range stats {
+		s.avgAge, s.medianAge = lineAges(s.authorTimes, revisionTime)
+		stats[actor] = s
+	}
+}
+
+func lineAges(authorTimes map[int64]int, revisionTime int64) (float64, float64) {
+	times := make([]int64, 0, len(authorTimes))
+	total := 0
+	for t, n := range authorTimes {
+		times = append(times, t)
+		total += n
+	}
+	if total == 0 {
+		return 0, 0
+	}
+
+	sort.Slice(times, func(i, j int) bool { return times[i] > times[j] })
+
+	var sum float64
+	var median int64
+	seen := 0
+	for _, t := range times {
+		n := authorTimes[t]
+		sum += float64(revisionTime-t) * float64(n)
+		if seen < (total+1)/2 && seen+n >= (total+1)/2 {
+			median = revisionTime - t
+		}
+		seen += n
+	}
+
+	return roundDays(sum / float64(total)), roundDays(float64(median))
+}
+
+func roundDays(seconds float64) float64 {
+	return math.Round(seconds/secondsPerDay*10) / 10
+}
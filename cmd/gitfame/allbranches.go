@@ -0,0 +1,172 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// branchTips returns the distinct commits of the branches selected by --all
+// or --branches, local and remote-tracking.
+func branchTips(config Config) []string {
+	var tips []string
+	seen := make(map[string]bool)
+	for _, branch := range listBranches(config) {
+		if config.Branches != "" {
+			if matched, _ := doublestar.Match(config.Branches, branch); !matched {
+				continue
+			}
+		}
+		commit, ok := resolveRevision(config, branch)
+		if !ok || seen[commit] {
+			continue
+		}
+		seen[commit] = true
+		tips = append(tips, commit)
+	}
+	sort.Strings(tips)
+	return tips
+}
+
+// branchesStats blames the files of every branch tip and counts each line
+// of history once: a line is identified by the commit it comes from and its
+// place in that commit, so lines shared by several branches, or copied
+// between them, are credited once and files are counted by path.
+func branchesStats(config Config) (map[string]ActorStats, []FileStats) {
+	type job struct {
+		tip  int
+		path string
+	}
+	type result struct {
+		job
+		groups  []blameGroup
+		kinds   lineKinds
+		weights []int
+		empty   *ActorStats
+	}
+
+	tips := branchTips(config)
+	jobs := make(chan job, config.Jobs)
+	go func() {
+		defer close(jobs)
+		for i, tip := range tips {
+			sub := config
+			sub.Revision = tip
+			for file := range parallelFilter(analyzedFiles(sub), sub) {
+				jobs <- job{tip: i, path: file}
+			}
+		}
+	}()
+
+	stage := config.tracer.start("blame", nil, "branches", strconv.Itoa(len(tips)))
+	var mu sync.Mutex
+	var results []result
+	var wg sync.WaitGroup
+	for w := 0; w < config.Jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				sub := config
+				sub.Revision = tips[j.tip]
+				span := config.tracer.start("blame-file", stage, "file", j.path, "revision", sub.Revision)
+
+				r := result{job: j}
+				cmd := gitCommand(sub, "blame", "--line-porcelain", j.path, sub.Revision)
+				var out bytes.Buffer
+				cmd.Stdout = &out
+				if err := cmd.Run(); err != nil {
+					span.finish()
+					config.tracer.count("skipped", 1)
+					continue
+				}
+				if out.Len() == 0 {
+					stats := infoEmptyFile(j.path, sub)
+					r.empty = &stats
+				}
+				forEachBlameGroup(out.String(), sub, func(g blameGroup) { r.groups = append(r.groups, g) })
+				lines := blameContent(out.String())
+				r.kinds, r.weights = fileKinds(j.path, lines, sub), lineWeights(j.path, lines, sub)
+				span.finish()
+				config.tracer.count("blamed", 1)
+
+				mu.Lock()
+				results = append(results, r)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	stage.finish()
+
+	// Partly shared groups are credited in branch and path order, so the
+	// results do not depend on the order the workers finish in.
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].tip != results[j].tip {
+			return results[i].tip < results[j].tip
+		}
+		return results[i].path < results[j].path
+	})
+
+	seen := make(map[string]bool)
+	byPath := make(map[string]map[string]ActorStats)
+	for _, r := range results {
+		if byPath[r.path] == nil {
+			byPath[r.path] = make(map[string]ActorStats)
+		}
+		if r.empty != nil {
+			if len(byPath[r.path]) == 0 {
+				byPath[r.path][r.empty.Name] = *r.empty
+			}
+			continue
+		}
+
+		for _, g := range r.groups {
+			unseen := 0
+			for i := 0; i < g.Count; i++ {
+				key := fmt.Sprintf("%s\x00%s\x00%d", g.Commit, g.Filename, g.OrigLine+i)
+				if !seen[key] {
+					seen[key] = true
+					unseen++
+					g.addLine(r.kinds.at(g.FinalLine+i), weightAt(r.weights, g.FinalLine+i), config)
+				}
+			}
+			if unseen == 0 {
+				continue
+			}
+			if unseen < g.Count {
+				g.Lines = unseen
+			}
+			g.applyCount(config)
+			addBlameGroup(byPath[r.path], g, config)
+		}
+	}
+
+	finalStats := make(map[string]ActorStats)
+	var fileStats []FileStats
+	for path, stats := range byPath {
+		if len(stats) == 0 {
+			continue
+		}
+		fileStats = append(fileStats, fileStatsOf(path, "", stats))
+		for actor, info := range stats {
+			if existing, ok := finalStats[actor]; ok {
+				finalStats[actor] = mergeActorStats(existing, info)
+			} else {
+				finalStats[actor] = info
+			}
+		}
+	}
+	for actor, stats := range finalStats {
+		stats.Commits = len(stats.commitsSet)
+		finalStats[actor] = stats
+	}
+	sort.Slice(fileStats, func(i, j int) bool { return fileStats[i].Path < fileStats[j].Path })
+	return finalStats, fileStats
+}
@@ -0,0 +1,65 @@
+//go:build !solution
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// pseudonym replaces a name or an email with a hash keyed by the
+// --anonymize-salt, the same in every run with the same salt.
+func pseudonym(s string, config Config) string {
+	mac := hmac.New(sha256.New, []byte(config.AnonymizeSalt))
+	mac.Write([]byte(s))
+	return "anon-" + hex.EncodeToString(mac.Sum(nil)[:5])
+}
+
+// anonymizedName is the pseudonym of an actor with --anonymize, for the
+// subcommands that print names without a Report.
+func anonymizedName(name string, config Config) string {
+	if !config.Anonymize {
+		return name
+	}
+	return pseudonym(name, config)
+}
+
+// anonymizeReport renames the actors of the report, in their stats and in
+// every file, and replaces their emails and the names of the other
+// identities of their commits, so that no output reveals them.
+func anonymizeReport(report Report, config Config) Report {
+	rename := func(counts map[string]int, email bool) map[string]int {
+		renamed := make(map[string]int, len(counts))
+		for key, n := range counts {
+			if email {
+				renamed[pseudonym(key, config)+"@anonymized.invalid"] += n
+			} else {
+				renamed[pseudonym(key, config)] += n
+			}
+		}
+		return renamed
+	}
+
+	anonymized := report
+	anonymized.Actors = make(map[string]ActorStats, len(report.Actors))
+	for actor, s := range report.Actors {
+		s.Name = pseudonym(actor, config)
+		s.emails = rename(s.emails, true)
+		s.roles = rename(s.roles, false)
+		s.account = forgeIdentity{}
+		anonymized.Actors[s.Name] = s
+	}
+
+	anonymized.Files = make([]FileStats, 0, len(report.Files))
+	for _, file := range report.Files {
+		file.Lines = rename(file.Lines, false)
+		commits := make(map[string]map[string]struct{}, len(file.Commits))
+		for actor, set := range file.Commits {
+			commits[pseudonym(actor, config)] = set
+		}
+		file.Commits = commits
+		anonymized.Files = append(anonymized.Files, file)
+	}
+	return anonymized
+}
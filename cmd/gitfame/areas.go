@@ -0,0 +1,39 @@
+//go:build !solution
+
+package main
+
+import "path"
+
+var areasColumn = column{Header: "TopAreas", Key: "top_areas", Value: func(a ActorStats) any { return a.areas }}
+
+// computeAreas sets the --top-areas directories of every actor, the most
+// owned lines first, with their share of the actor's lines. The lines of a
+// directory do not include its subdirectories.
+func computeAreas(stats map[string]ActorStats, files []FileStats, config Config) {
+	if config.TopAreas == 0 {
+		return
+	}
+
+	dirs := make(map[string]map[string]int)
+	for _, file := range files {
+		dir := path.Dir(file.Path)
+		for actor, n := range file.Lines {
+			if dirs[actor] == nil {
+				dirs[actor] = make(map[string]int)
+			}
+			dirs[actor][dir] += n
+		}
+	}
+
+	for actor, s := range stats {
+		total := 0
+		for _, n := range dirs[actor] {
+			total += n
+		}
+		s.areas = make([]string, 0, config.TopAreas)
+		for _, dir := range topNames(dirs[actor], config.TopAreas) {
+			s.areas = append(s.areas, dir+" ("+formatValue(percent(dirs[actor][dir], total))+"%)")
+		}
+		stats[actor] = s
+	}
+}
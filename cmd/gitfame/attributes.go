@@ -0,0 +1,116 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var linguistAttributes = []string{
+	"linguist-vendored",
+	"linguist-generated",
+	"linguist-documentation",
+	"linguist-language",
+}
+
+// attributeCache memoizes the linguist attributes of every file by revision.
+type attributeCache struct {
+	mu         sync.Mutex
+	byRevision map[string]map[string]map[string]string
+}
+
+// fileAttributes returns the linguist attributes of the file at the revision,
+// as reported by `git check-attr`.
+func fileAttributes(file string, config Config) map[string]string {
+	if config.IgnoreGitattributes || config.attributes == nil {
+		return nil
+	}
+
+	c := config.attributes
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	attrs, ok := c.byRevision[config.Revision]
+	if !ok {
+		attrs = loadAttributes(config)
+		if c.byRevision == nil {
+			c.byRevision = make(map[string]map[string]map[string]string)
+		}
+		c.byRevision[config.Revision] = attrs
+	}
+	return attrs[file]
+}
+
+// loadAttributes reads the .gitattributes of the revision rather than of the
+// working tree by checking attributes against a temporary index.
+func loadAttributes(config Config) map[string]map[string]string {
+	files := getFiles(config)
+	if len(files) == 0 {
+		return nil
+	}
+
+	dir, err := os.MkdirTemp("", "gitfame-index-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка создания временного индекса: %v\n", err)
+		return nil
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	indexEnv := "GIT_INDEX_FILE=" + filepath.Join(dir, "index")
+
+	readTree := gitCommand(config, "read-tree", config.Revision)
+	readTree.Env = append(readTree.Env, indexEnv)
+	if err := readTree.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git read-tree: %v\n", err)
+		return nil
+	}
+
+	cmd := gitCommand(config, append([]string{"check-attr", "--cached", "--stdin", "-z"}, linguistAttributes...)...)
+	cmd.Env = append(cmd.Env, indexEnv)
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\x00"))
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git check-attr: %v\n", err)
+		return nil
+	}
+
+	attrs := make(map[string]map[string]string)
+	fields := strings.Split(out.String(), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		file, attr, value := fields[i], fields[i+1], fields[i+2]
+		if value == "unspecified" {
+			continue
+		}
+		if attrs[file] == nil {
+			attrs[file] = make(map[string]string)
+		}
+		attrs[file][attr] = value
+	}
+	return attrs
+}
+
+// excludedByAttributes skips files marked as vendored, generated or
+// documentation.
+func excludedByAttributes(file string, config Config) bool {
+	attrs := fileAttributes(file, config)
+	for _, attr := range linguistAttributes[:3] {
+		if value := attrs[attr]; value == "set" || value == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// attributeLanguage returns the language forced with linguist-language.
+func attributeLanguage(file string, config Config) string {
+	value := fileAttributes(file, config)["linguist-language"]
+	if value == "" || value == "set" || value == "unset" {
+		return ""
+	}
+	return strings.ToLower(value)
+}
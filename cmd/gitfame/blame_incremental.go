@@ -0,0 +1,84 @@
+//go:build !solution
+
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// incrementalBlame runs `git blame --incremental`, which prints the headers
+// of a commit only with its first group and no line content. The content
+// needed for lone CRs and the EOL report comes from the blob.
+func incrementalBlame(file string, config Config) (string, []string, bool) {
+	out, err := gitCommand(config, "blame", "--incremental", config.Revision, "--", file).Output()
+	if err != nil {
+		return "", nil, false
+	}
+
+	content, ok := blobPrefix(file, 1<<62, config)
+	if !ok {
+		if content, err = gitCommand(config, "cat-file", "blob", config.Revision+":"+file).Output(); err != nil {
+			return "", nil, false
+		}
+	}
+
+	var lines []string
+	if len(content) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	}
+	return string(out), lines, true
+}
+
+// forEachIncrementalGroup calls fn for every group of `git blame
+// --incremental` output, in the order git finds them. Lines holds the
+// content of the file for the lone CR count.
+func forEachIncrementalGroup(out string, lines []string, config Config, fn func(g blameGroup)) {
+	headers := make(map[string]map[string]string)
+
+	var commitHash string
+	var orig, final, nLines int
+	for _, line := range strings.Split(out, "\n") {
+		if hash, origLine, start, n, ok := parseGroupHeader(line); ok {
+			commitHash, orig, final, nLines = hash, origLine, start, n
+			if headers[commitHash] == nil {
+				headers[commitHash] = make(map[string]string)
+			}
+			continue
+		}
+		if commitHash == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		if key != "filename" {
+			headers[commitHash][key] = value
+			continue
+		}
+
+		// The filename line ends the group.
+		h := headers[commitHash]
+		actor, actorMail := groupActor(commitHash, h["author"], h["author-mail"], h["committer"], h["committer-mail"], config)
+		authorTime, _ := strconv.ParseInt(h["author-time"], 10, 64)
+
+		count := nLines
+		for i := final - 1; i >= 0 && i < final-1+nLines && i < len(lines); i++ {
+			count += blameContentLines(lines[i]) - 1
+		}
+
+		fn(blameGroup{
+			Commit:     commitHash,
+			Actor:      actor,
+			Author:     h["author"],
+			Committer:  h["committer"],
+			ActorMail:  strings.Trim(actorMail, "<>"),
+			AuthorTime: authorTime,
+			Lines:      count,
+			Filename:   value,
+			OrigLine:   orig,
+			Count:      nLines,
+			FinalLine:  final,
+		})
+		commitHash = ""
+	}
+}
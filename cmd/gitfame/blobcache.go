@@ -0,0 +1,100 @@
+//go:build !solution
+
+package main
+
+import (
+	"sort"
+	"sync"
+)
+
+// Empty blobs are attributed by the history of their own path.
+var emptyBlobs = map[string]bool{
+	"e69de29bb2d1d6434b8b29ae775ad8c2e48c5391":                         true,
+	"473a0f4c3be8a93681a267e3b1e9a7dcda1185436fe141f7749120a303721813": true,
+}
+
+// blobCache maps the paths of the revision to their blobs. With
+// --dedupe-blobs it also blames a blob shared by several paths, such as a
+// vendored or copied file, only once. The results of the first of the paths
+// in sort order are used for all of them, so they do not depend on the
+// order the workers get the files in, but the lines of a copy stay with the
+// authors of the first path instead of whoever added the copy.
+type blobCache struct {
+	// objects maps the paths to their blobs.
+	objects map[string]string
+	// canonical maps the paths of duplicated blobs to the blamed path.
+	canonical map[string]string
+
+	mu      sync.Mutex
+	results map[string]*blobResult
+}
+
+type blobResult struct {
+	once  sync.Once
+	stats map[string]ActorStats
+	eol   string
+}
+
+func newBlobCache(config Config) *blobCache {
+	c := &blobCache{objects: make(map[string]string), canonical: make(map[string]string), results: make(map[string]*blobResult)}
+	paths := make(map[string][]string)
+	for _, entry := range listTree(config) {
+		if entry.Type != "blob" || entry.Mode == symlinkMode {
+			continue
+		}
+		c.objects[entry.Path] = entry.Object
+		if config.DedupeBlobs && !emptyBlobs[entry.Object] {
+			paths[entry.Object] = append(paths[entry.Object], entry.Path)
+		}
+	}
+
+	for _, same := range paths {
+		if len(same) < 2 {
+			continue
+		}
+		sort.Strings(same)
+		for _, path := range same {
+			c.canonical[path] = same[0]
+		}
+	}
+	return c
+}
+
+// stats returns the results of blame for the file, calling blame at most
+// once per deduplicated blob. Every caller gets its own copy of the stats; reused reports
+// whether another path blamed the blob.
+func (c *blobCache) stats(file string, blame func(file string) (map[string]ActorStats, string)) (stats map[string]ActorStats, eol string, reused bool) {
+	canonical, ok := c.canonical[file]
+	if !ok {
+		stats, eol = blame(file)
+		return stats, eol, false
+	}
+
+	c.mu.Lock()
+	var result *blobResult
+	result, reused = c.results[canonical]
+	if !reused {
+		result = &blobResult{}
+		c.results[canonical] = result
+	}
+	c.mu.Unlock()
+
+	result.once.Do(func() { result.stats, result.eol = blame(canonical) })
+	if result.stats == nil {
+		return nil, "", reused
+	}
+	stats = make(map[string]ActorStats, len(result.stats))
+	for actor, info := range result.stats {
+		stats[actor] = copyActorStats(info)
+	}
+	return stats, result.eol, reused
+}
+
+// copyActorStats returns stats that share no maps with info.
+func copyActorStats(info ActorStats) ActorStats {
+	c := info
+	c.Lines, c.Files, c.added, c.linesLow, c.linesHigh = 0, 0, 0, 0, 0
+	c.code, c.comments, c.blank, c.weighted, c.knowledge = 0, 0, 0, 0, 0
+	c.commitsSet, c.authorTimes, c.roles, c.emails, c.velocity, c.trend = nil, nil, nil, nil, nil, nil
+	return mergeActorStats(c, info)
+}
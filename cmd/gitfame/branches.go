@@ -0,0 +1,188 @@
+//go:build !solution
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type BranchRow struct {
+	Branch     string
+	Commits    int
+	LastCommit int64
+	Authors    map[string]int
+}
+
+func newBranchesCmd(config *Config) *cobra.Command {
+	var stale string
+
+	cmd := &cobra.Command{
+		Use:   "branches",
+		Short: "Lists branches whose unique commits belong only to inactive authors",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := parseWindow(stale)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid stale window: %s\n", stale)
+				os.Exit(2)
+			}
+
+			rows := staleBranches(*config, w)
+
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				return writeTable(out, branchesTable(rows, config), nil, config)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&stale, "stale", "90d", "Authors without commits in this window before the revision date are inactive")
+
+	return cmd
+}
+
+// staleBranches reports every branch with commits not reachable from the
+// revision, all of which were made by actors inactive for the whole window.
+func staleBranches(config Config, w window) []BranchRow {
+	lastSeen := lastActivity(config, "--all")
+	cutoff := commitTime(config, config.Revision) - int64(w.Duration/time.Second)
+
+	var rows []BranchRow
+	for _, branch := range listBranches(config) {
+		row := BranchRow{Branch: branch, Authors: make(map[string]int)}
+		for actor, times := range uniqueCommits(config, branch) {
+			row.Authors[actor] = len(times)
+			row.Commits += len(times)
+			for _, t := range times {
+				row.LastCommit = max(row.LastCommit, t)
+			}
+		}
+		if row.Commits == 0 {
+			continue
+		}
+
+		stale := true
+		for actor := range row.Authors {
+			if lastSeen[actor] >= cutoff {
+				stale = false
+				break
+			}
+		}
+		if stale {
+			rows = append(rows, row)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].LastCommit != rows[j].LastCommit {
+			return rows[i].LastCommit < rows[j].LastCommit
+		}
+		return rows[i].Branch < rows[j].Branch
+	})
+	return rows
+}
+
+func listBranches(config Config) []string {
+	cmd := gitCommand(config, "for-each-ref",
+		"--format=%(refname:short)%00%(symref)", "refs/heads", "refs/remotes")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git for-each-ref: %v\n", err)
+		return nil
+	}
+
+	var branches []string
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		name, symref, _ := strings.Cut(scanner.Text(), "\x00")
+		// Skip aliases such as origin/HEAD.
+		if symref != "" {
+			continue
+		}
+		branches = append(branches, name)
+	}
+	return branches
+}
+
+// lastActivity returns the latest commit time of every actor in the given
+// range of history.
+func lastActivity(config Config, revisions ...string) map[string]int64 {
+	last := make(map[string]int64)
+	forEachCommit(config, revisions, func(actor string, t int64) {
+		last[actor] = max(last[actor], t)
+	})
+	return last
+}
+
+// uniqueCommits returns the commit times of every actor on the branch that are
+// not reachable from the revision.
+func uniqueCommits(config Config, branch string) map[string][]int64 {
+	commits := make(map[string][]int64)
+	forEachCommit(config, []string{branch, "--not", config.Revision}, func(actor string, t int64) {
+		commits[actor] = append(commits[actor], t)
+	})
+	return commits
+}
+
+func forEachCommit(config Config, revisions []string, fn func(actor string, t int64)) {
+	format := "%at%x00%an"
+	if config.UseCommitter {
+		format = "%ct%x00%cn"
+	}
+
+	cmd := gitCommand(config, append([]string{"log", "--no-merges", "--format=" + format}, revisions...)...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git log: %v\n", err)
+		return
+	}
+
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		timestamp, actor, ok := strings.Cut(scanner.Text(), "\x00")
+		if !ok {
+			continue
+		}
+		t, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			continue
+		}
+		fn(actor, t)
+	}
+}
+
+func branchesTable(rows []BranchRow, config Config) table {
+	t := table{
+		Headers: []string{"Branch", "Commits", "LastCommit", "Authors"},
+		Rows:    make([][]string, 0, len(rows)),
+		Items:   make([]record, 0, len(rows)),
+	}
+	for _, row := range rows {
+		lines := make(map[string]int, len(row.Authors))
+		for name, n := range row.Authors {
+			lines[anonymizedName(name, config)] += n
+		}
+		authors := topWithLines(lines, len(lines))
+		t.Rows = append(t.Rows, []string{row.Branch, strconv.Itoa(row.Commits), formatDate(row.LastCommit), formatValue(authors)})
+		t.Items = append(t.Items, record{
+			{Key: "branch", Value: row.Branch},
+			{Key: "commits", Value: row.Commits},
+			{Key: "last_commit", Value: formatDate(row.LastCommit)},
+			{Key: "authors", Value: authors},
+		})
+	}
+	return t
+}
@@ -0,0 +1,90 @@
+//go:build !solution
+
+package main
+
+import (
+	"sort"
+	"strconv"
+)
+
+const unknownLanguage = "other"
+
+type breakdownRow struct {
+	Key string
+	ActorStats
+}
+
+// breakdownHeaders names the key column of every --breakdown dimension.
+var breakdownHeaders = map[string]string{
+	"language":   "Language",
+	"repository": "Repository",
+}
+
+// breakdownKey returns the language of the file, as classified by the
+// extensions map, or the repository it comes from.
+func breakdownKey(file string, dimension string, config Config) string {
+	if dimension == "repository" {
+		return repositoryOf(file, config)
+	}
+	if lang := languageOf(file, config); lang != "" {
+		return lang
+	}
+	return unknownLanguage
+}
+
+// breakdownTable splits every actor's stats by the given dimension of the
+// files.
+func breakdownTable(files []FileStats, dimension string, config Config) table {
+	type key struct{ actor, value string }
+
+	stats := make(map[key]ActorStats)
+	for _, file := range files {
+		value := breakdownKey(file.Path, dimension, config)
+
+		for actor, n := range file.Lines {
+			k := key{actor: actor, value: value}
+			s, ok := stats[k]
+			if !ok {
+				s = ActorStats{Name: actor, commitsSet: make(map[string]struct{})}
+			}
+			s.Lines += n
+			s.Files++
+			for commit := range file.Commits[actor] {
+				s.commitsSet[commit] = struct{}{}
+			}
+			stats[k] = s
+		}
+	}
+
+	rows := make([]breakdownRow, 0, len(stats))
+	for k, s := range stats {
+		s.Commits = len(s.commitsSet)
+		rows = append(rows, breakdownRow{Key: k.value, ActorStats: s})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if lessActors(rows[i].ActorStats, rows[j].ActorStats, config) {
+			return true
+		}
+		if lessActors(rows[j].ActorStats, rows[i].ActorStats, config) {
+			return false
+		}
+		return rows[i].Key < rows[j].Key
+	})
+
+	t := table{
+		Headers: []string{"Name", breakdownHeaders[dimension], "Lines", "Commits", "Files"},
+		Rows:    make([][]string, 0, len(rows)),
+		Items:   make([]record, 0, len(rows)),
+	}
+	for _, r := range rows {
+		t.Rows = append(t.Rows, []string{r.Name, r.Key, strconv.Itoa(r.Lines), strconv.Itoa(r.Commits), strconv.Itoa(r.Files)})
+		t.Items = append(t.Items, record{
+			{Key: "name", Value: r.Name},
+			{Key: dimension, Value: r.Key},
+			{Key: "lines", Value: r.Lines},
+			{Key: "commits", Value: r.Commits},
+			{Key: "files", Value: r.Files},
+		})
+	}
+	return t
+}
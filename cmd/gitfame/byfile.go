@@ -0,0 +1,97 @@
+//go:build !solution
+
+package main
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+type byFileRow struct {
+	Path    string
+	Owner   string
+	Share   float64
+	Lines   int
+	Authors int
+	Entropy float64
+}
+
+// byFileTable is the ownership map of --by-file: every file with its top
+// owner, the owner's share of its lines, the number of authors and the
+// entropy of the lines by author, in path order or, with
+// --by-file-sort=entropy, the files known by the fewest people first. With
+// --owner-above only the files whose owner holds more than that share are
+// listed.
+func byFileTable(files []FileStats, config Config) table {
+	threshold, _ := parsePercent(config.OwnerAbove)
+
+	rows := make([]byFileRow, 0, len(files))
+	for _, file := range files {
+		owner, total, share := topOwner(file.Lines)
+		if config.OwnerAbove != "" && share <= threshold {
+			continue
+		}
+		authors := 0
+		for _, n := range file.Lines {
+			if n > 0 {
+				authors++
+			}
+		}
+		rows = append(rows, byFileRow{
+			Path:    formatPath(file.Path, config),
+			Owner:   owner,
+			Share:   share,
+			Lines:   total,
+			Authors: authors,
+			Entropy: entropy(file.Lines),
+		})
+	}
+	if config.ByFileSort == "entropy" {
+		sort.SliceStable(rows, func(i, j int) bool {
+			if rows[i].Entropy != rows[j].Entropy {
+				return rows[i].Entropy < rows[j].Entropy
+			}
+			return rows[i].Lines > rows[j].Lines
+		})
+	}
+
+	t := table{
+		Headers: []string{"File", "Owner", "Share%", "Lines", "Authors", "Entropy"},
+		Rows:    make([][]string, 0, len(rows)),
+		Items:   make([]record, 0, len(rows)),
+	}
+	for _, r := range rows {
+		t.Rows = append(t.Rows, []string{r.Path, r.Owner, formatValue(r.Share), strconv.Itoa(r.Lines), strconv.Itoa(r.Authors), formatValue(r.Entropy)})
+		t.Items = append(t.Items, record{
+			{Key: "file", Value: r.Path},
+			{Key: "owner", Value: r.Owner},
+			{Key: "share", Value: r.Share},
+			{Key: "lines", Value: r.Lines},
+			{Key: "authors", Value: r.Authors},
+			{Key: "entropy", Value: r.Entropy},
+		})
+	}
+	return t
+}
+
+// entropy returns the Shannon entropy in bits of the line shares: 0 when one
+// actor owns the file, log2(N) for N actors with equal shares.
+func entropy(lines map[string]int) float64 {
+	total := 0
+	for _, n := range lines {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	var h float64
+	for _, n := range lines {
+		if n > 0 {
+			p := float64(n) / float64(total)
+			h -= p * math.Log2(p)
+		}
+	}
+	return round3(h)
+}
@@ -0,0 +1,144 @@
+//go:build !solution
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// catFile is a long-lived `git cat-file --batch` or `--batch-check` process
+// answering object queries one at a time.
+type catFile struct {
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	failed bool
+}
+
+var (
+	catFilesMu sync.Mutex
+	// catFiles are the running processes by repository path and mode.
+	catFiles = make(map[string]*catFile)
+)
+
+// catFileFor returns the process of the repository, starting it on first use.
+func catFileFor(config Config, mode string) *catFile {
+	catFilesMu.Lock()
+	defer catFilesMu.Unlock()
+
+	key := config.Repository + "\x00" + mode
+	if c, ok := catFiles[key]; ok {
+		return c
+	}
+
+	c := &catFile{cmd: gitCommand(config, "cat-file", mode)}
+	stdin, err := c.cmd.StdinPipe()
+	if err == nil {
+		var stdout io.ReadCloser
+		if stdout, err = c.cmd.StdoutPipe(); err == nil {
+			c.stdin, c.stdout = stdin, bufio.NewReader(stdout)
+			err = c.cmd.Start()
+		}
+	}
+	if err != nil {
+		c.failed = true
+	}
+	catFiles[key] = c
+	return c
+}
+
+// stopCatFiles closes the input of every process and waits for them to exit.
+func stopCatFiles() {
+	catFilesMu.Lock()
+	defer catFilesMu.Unlock()
+
+	for key, c := range catFiles {
+		if !c.failed {
+			_ = c.stdin.Close()
+			_ = c.cmd.Wait()
+		}
+		delete(catFiles, key)
+	}
+}
+
+// query writes the object name and parses the "<oid> <type> <size>" header of
+// the answer. Missing objects return false.
+func (c *catFile) query(object string) (int64, bool, error) {
+	if _, err := io.WriteString(c.stdin, object+"\n"); err != nil {
+		return 0, false, err
+	}
+	header, err := c.stdout.ReadString('\n')
+	if err != nil {
+		return 0, false, err
+	}
+
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return 0, false, nil
+	}
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("cat-file header %q: %w", strings.TrimSpace(header), err)
+	}
+	return size, true, nil
+}
+
+// blobSize returns the size of the file at the revision; ok is false when
+// the size is unknown and the caller should not rely on it.
+func blobSize(file string, config Config) (int64, bool) {
+	if strings.Contains(file, "\n") {
+		return 0, false
+	}
+	c := catFileFor(config, "--batch-check")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failed {
+		return 0, false
+	}
+
+	size, ok, err := c.query(config.Revision + ":" + file)
+	if err != nil {
+		c.failed = true
+		return 0, false
+	}
+	return size, ok
+}
+
+// blobPrefix returns up to limit bytes of the file at the revision.
+func blobPrefix(file string, limit int64, config Config) ([]byte, bool) {
+	if strings.Contains(file, "\n") {
+		return nil, false
+	}
+	c := catFileFor(config, "--batch")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failed {
+		return nil, false
+	}
+
+	size, ok, err := c.query(config.Revision + ":" + file)
+	if err == nil && !ok {
+		return nil, true
+	}
+	var content []byte
+	if err == nil {
+		// The whole blob and its trailing newline have to be read to get to
+		// the next answer.
+		content = make([]byte, min(size, limit))
+		if _, err = io.ReadFull(c.stdout, content); err == nil {
+			_, err = c.stdout.Discard(int(size - int64(len(content)) + 1))
+		}
+	}
+	if err != nil {
+		c.failed = true
+		return nil, false
+	}
+	return content, true
+}
@@ -0,0 +1,71 @@
+//go:build !solution
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// readCgroupFile reads a cgroup v2 interface file of the current process,
+// falling back to the root of the mount inside containers with a private
+// cgroup namespace.
+func readCgroupFile(name string) ([]byte, error) {
+	if data, err := os.ReadFile("/proc/self/cgroup"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if path, ok := strings.CutPrefix(line, "0::"); ok {
+				if data, err := os.ReadFile(filepath.Join(cgroupRoot, path, name)); err == nil {
+					return data, nil
+				}
+			}
+		}
+	}
+	return os.ReadFile(filepath.Join(cgroupRoot, name))
+}
+
+// cgroupCPULimit reads the cgroup v2 CPU quota, rounded up to whole CPUs.
+func cgroupCPULimit() (int, bool) {
+	data, err := readCgroupFile("cpu.max")
+	if err != nil {
+		return 0, false
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0, false
+	}
+
+	quota, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || quota <= 0 {
+		return 0, false
+	}
+	period, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || period <= 0 {
+		return 0, false
+	}
+
+	return int((quota + period - 1) / period), true
+}
+
+// cgroupMemoryLimit reads the cgroup v2 memory limit in bytes.
+func cgroupMemoryLimit() (int64, bool) {
+	data, err := readCgroupFile("memory.max")
+	if err != nil {
+		return 0, false
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, false
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit <= 0 {
+		return 0, false
+	}
+	return limit, true
+}
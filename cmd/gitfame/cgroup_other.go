@@ -0,0 +1,11 @@
+//go:build !solution && !linux
+
+package main
+
+func cgroupCPULimit() (int, bool) {
+	return 0, false
+}
+
+func cgroupMemoryLimit() (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,70 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// chartWidth is the length of the longest bar in cells.
+const chartWidth = 40
+
+// chartBlocks are the partial blocks ending a bar, in eighths of a cell.
+var chartBlocks = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// writeChart draws a bar of the --metric column for every row of the table,
+// scaled to the largest value, with the value and its share of the total.
+func writeChart(out io.Writer, t table, config Config) error {
+	names := make([]string, 0, len(t.Items))
+	values := make([]int, 0, len(t.Items))
+	for _, item := range t.Items {
+		var name string
+		value, found := 0, false
+		for _, f := range item {
+			switch f.Key {
+			case "name":
+				name = formatValue(f.Value)
+			case config.Metric:
+				value, found = f.Value.(int)
+			}
+		}
+		if !found {
+			return fmt.Errorf("chart needs the %s column", config.Metric)
+		}
+		names = append(names, name)
+		values = append(values, value)
+	}
+
+	width, peak, total := 0, 0, 0
+	for i, name := range names {
+		if config.MaxColumnWidth > 0 {
+			names[i] = truncateWidth(name, config.MaxColumnWidth)
+		}
+		width = max(width, displayWidth(names[i]))
+		peak = max(peak, values[i])
+		total += values[i]
+	}
+
+	color := colorEnabled(config)
+	for i, name := range names {
+		eighths := 0
+		if peak > 0 {
+			eighths = values[i] * chartWidth * 8 / peak
+		}
+		bar := strings.Repeat("█", eighths/8) + chartBlocks[eighths%8]
+		if bar != "" {
+			if color {
+				bar = ansiShare + bar + ansiReset
+			}
+			bar += " "
+		}
+		_, err := fmt.Fprintf(out, "%s%s %s%d (%v%%)\n",
+			name, strings.Repeat(" ", width-displayWidth(name)), bar, values[i], percent(values[i], total))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+//go:build !solution
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// checkpoint appends the blame results of every file to a JSON lines file as
+// they complete, so that an interrupted run can be resumed with --resume.
+// The first line records the options the results depend on.
+type checkpoint struct {
+	mu      sync.Mutex
+	f       *os.File
+	entries map[string]checkpointEntry
+}
+
+type checkpointHeader struct {
+	Options string `json:"options"`
+}
+
+// checkpointEntry is the result of one file at one commit.
+type checkpointEntry struct {
+	Revision string            `json:"revision"`
+	Path     string            `json:"path"`
+	EOL      string            `json:"eol,omitempty"`
+	Actors   []checkpointActor `json:"actors"`
+}
+
+type checkpointActor struct {
+	Name        string         `json:"name"`
+	Lines       int            `json:"lines"`
+	Commits     []string       `json:"commits"`
+	AuthorTimes map[int64]int  `json:"author_times,omitempty"`
+	Roles       map[string]int `json:"roles,omitempty"`
+	Emails      map[string]int `json:"emails,omitempty"`
+	Code        int            `json:"code,omitempty"`
+	Comments    int            `json:"comments,omitempty"`
+	Blank       int            `json:"blank,omitempty"`
+	Weighted    int            `json:"weighted,omitempty"`
+}
+
+// checkpointOptions lists the options that change the blame results of a file.
+func checkpointOptions(config Config) string {
+	return "use-committer=" + strconv.FormatBool(config.UseCommitter) +
+		",import-boundary=" + config.ImportBoundary +
+		",forge-attribution=" + config.ForgeAttribution +
+		",count=" + config.Count +
+		",exclude-license-headers=" + strconv.FormatBool(config.ExcludeLicenses) +
+		",weighted=" + strconv.FormatBool(config.Weighted) +
+		",detect=" + config.Detect +
+		",languages-file=" + fileDigest(config.LanguagesFile)
+}
+
+// fileDigest identifies the contents of the file given to an option, so that
+// editing it invalidates the recorded results.
+func fileDigest(path string) string {
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return path
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))[:16]
+}
+
+// openCheckpoint starts a new checkpoint file or, when resuming, loads the
+// results of an earlier run with the same options and appends to it.
+func openCheckpoint(config Config) (*checkpoint, error) {
+	c := &checkpoint{entries: make(map[string]checkpointEntry)}
+	options := checkpointOptions(config)
+
+	if config.Resume {
+		f, err := os.Open(config.Checkpoint)
+		if err == nil {
+			err = c.load(f, options)
+			_ = f.Close()
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if len(c.entries) == 0 {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(config.Checkpoint, flags, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	c.f = f
+
+	if len(c.entries) == 0 {
+		if err := c.write(checkpointHeader{Options: options}); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *checkpoint) load(f *os.File, options string) error {
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	if !scanner.Scan() {
+		return scanner.Err()
+	}
+	var header checkpointHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return fmt.Errorf("%s: %w", f.Name(), err)
+	}
+	if header.Options != options {
+		return fmt.Errorf("%s was written with %s, not %s", f.Name(), header.Options, options)
+	}
+
+	for scanner.Scan() {
+		var entry checkpointEntry
+		// The last line is cut short when the run was killed mid-write.
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		c.entries[entry.Revision+":"+entry.Path] = entry
+	}
+	return scanner.Err()
+}
+
+func (c *checkpoint) write(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = c.f.Write(append(data, '\n'))
+	return err
+}
+
+// stats returns the recorded results of the file at the revision.
+func (c *checkpoint) stats(revision, file string) (map[string]ActorStats, string, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[revision+":"+file]
+	c.mu.Unlock()
+	if !ok {
+		return nil, "", false
+	}
+	return recordedStats(entry.Actors), entry.EOL, true
+}
+
+// recordedStats turns recorded actors back into the stats of a file. The
+// stats get their own maps, since merging them into the totals modifies them.
+func recordedStats(actors []checkpointActor) map[string]ActorStats {
+	stats := make(map[string]ActorStats, len(actors))
+	for _, a := range actors {
+		s := ActorStats{
+			Name:        a.Name,
+			Lines:       a.Lines,
+			Files:       1,
+			commitsSet:  make(map[string]struct{}, len(a.Commits)),
+			authorTimes: maps.Clone(a.AuthorTimes),
+			roles:       maps.Clone(a.Roles),
+			emails:      maps.Clone(a.Emails),
+			code:        a.Code,
+			comments:    a.Comments,
+			blank:       a.Blank,
+			weighted:    a.Weighted,
+		}
+		for _, commit := range a.Commits {
+			s.commitsSet[commit] = struct{}{}
+		}
+		stats[a.Name] = s
+	}
+	return stats
+}
+
+// record appends the results of the file; write errors only disable the
+// checkpoint, the run goes on.
+func (c *checkpoint) record(revision, file string, stats map[string]ActorStats, eol string) {
+	entry := checkpointEntry{Revision: revision, Path: file, EOL: eol, Actors: recordActors(stats)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.f == nil {
+		return
+	}
+	if err := c.write(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Checkpoint disabled: %v\n", err)
+		_ = c.f.Close()
+		c.f = nil
+	}
+}
+
+// recordActors copies the stats of a file for recording: the maps of the
+// stats are merged into the totals of the run after this.
+func recordActors(stats map[string]ActorStats) []checkpointActor {
+	actors := make([]checkpointActor, 0, len(stats))
+	for _, s := range stats {
+		actors = append(actors, checkpointActor{
+			Name:        s.Name,
+			Lines:       s.Lines,
+			Commits:     sortedKeys(s.commitsSet),
+			AuthorTimes: maps.Clone(s.authorTimes),
+			Roles:       maps.Clone(s.roles),
+			Emails:      maps.Clone(s.emails),
+			Code:        s.code,
+			Comments:    s.comments,
+			Blank:       s.blank,
+			Weighted:    s.weighted,
+		})
+	}
+	return actors
+}
+
+// checkpointedStats blames the file unless the checkpoint already has its
+// results at the revision commit.
+func checkpointedStats(file, revision string, config Config) (map[string]ActorStats, string) {
+	if config.checkpoint == nil {
+		return calculateStats(file, config)
+	}
+	if stats, eol, ok := config.checkpoint.stats(revision, file); ok {
+		return stats, eol
+	}
+
+	stats, eol := calculateStats(file, config)
+	if stats != nil {
+		config.checkpoint.record(revision, file, stats, eol)
+	}
+	return stats, eol
+}
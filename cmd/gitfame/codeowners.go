@@ -0,0 +1,154 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newCodeownersCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "codeowners",
+		Short: "Generates and checks CODEOWNERS files against line ownership",
+	}
+	cmd.AddCommand(newCodeownersGenerateCmd(config))
+	cmd.AddCommand(newCodeownersCheckCmd(config))
+	return cmd
+}
+
+func newCodeownersGenerateCmd(config *Config) *cobra.Command {
+	var threshold string
+
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Prints a CODEOWNERS file listing the owners of at least --threshold of every directory",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Owners of a CODEOWNERS file must be real accounts.
+			if config.Anonymize {
+				fmt.Fprintf(os.Stderr, "--anonymize is not supported by codeowners\n")
+				os.Exit(2)
+			}
+			limit, err := parsePercent(threshold)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid threshold: %s\n", threshold)
+				os.Exit(2)
+			}
+
+			report := collectStats(*config)
+			rules := codeownersRules(report, limit, *config)
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				fmt.Fprintf(out, "# Generated by gitfame codeowners generate --threshold %s%% at %s\n", formatValue(limit), config.revisionName)
+				for _, rule := range rules {
+					fmt.Fprintf(out, "%s %s\n", rule.Pattern, strings.Join(rule.Owners, " "))
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&threshold, "threshold", "50", "Share of the lines of a directory an owner must hold, like 60%")
+
+	return cmd
+}
+
+type codeownersRule struct {
+	Pattern string
+	Owners  []string
+}
+
+// codeownersOwner returns the CODEOWNERS owner of an actor: the owners map of
+// the config file by name or email, or the primary email.
+func codeownersOwner(actor string, report Report, config Config) string {
+	if owner, ok := config.File.Owners[actor]; ok {
+		return owner
+	}
+	s := report.Actors[actor]
+	for _, email := range topNames(s.emails, len(s.emails)) {
+		if owner, ok := config.File.Owners[email]; ok {
+			return owner
+		}
+	}
+	return primaryEmail(s)
+}
+
+// ownerLines sums the lines of the actors by their CODEOWNERS owner, so that
+// members of a team count together.
+func ownerLines(lines map[string]int, report Report, config Config) map[string]int {
+	owners := make(map[string]int)
+	for actor, n := range lines {
+		if owner := codeownersOwner(actor, report, config); owner != "" {
+			owners[owner] += n
+		}
+	}
+	return owners
+}
+
+// qualifiedOwners returns the owners holding at least limit percent of the
+// lines, the biggest first.
+func qualifiedOwners(lines map[string]int, limit float64) []string {
+	total := 0
+	for _, n := range lines {
+		total += n
+	}
+
+	var owners []string
+	for _, owner := range topNames(lines, len(lines)) {
+		if total > 0 && percent(lines[owner], total) >= limit {
+			owners = append(owners, owner)
+		}
+	}
+	return owners
+}
+
+// codeownersRules returns a rule for the repository root and for every
+// directory whose qualified owners differ from the rule it would inherit.
+// Directories where nobody reaches the limit inherit the parent rule.
+func codeownersRules(report Report, limit float64, config Config) []codeownersRule {
+	dirs := directoryLines(report.Files)
+	names := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		names = append(names, dir)
+	}
+	sort.Strings(names)
+
+	var rules []codeownersRule
+	inherited := make(map[string]string)
+	for _, dir := range names {
+		parent := ""
+		for p := dir; p != "."; {
+			p = parentDir(p)
+			if owners, ok := inherited[p]; ok {
+				parent = owners
+				break
+			}
+		}
+
+		owners := qualifiedOwners(ownerLines(dirs[dir], report, config), limit)
+		key := strings.Join(owners, " ")
+		if len(owners) == 0 || key == parent {
+			continue
+		}
+		inherited[dir] = key
+
+		pattern := "*"
+		if dir != "." {
+			pattern = "/" + strings.ReplaceAll(dir, " ", "\\ ") + "/"
+		}
+		rules = append(rules, codeownersRule{Pattern: pattern, Owners: owners})
+	}
+	return rules
+}
+
+func parentDir(dir string) string {
+	if i := strings.LastIndex(dir, "/"); i >= 0 {
+		return dir[:i]
+	}
+	return "."
+}
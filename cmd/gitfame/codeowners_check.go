@@ -0,0 +1,220 @@
+//go:build !solution
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/spf13/cobra"
+)
+
+// codeownersLocations are the places GitHub looks for CODEOWNERS, in order.
+var codeownersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+func newCodeownersCheckCmd(config *Config) *cobra.Command {
+	var file, minShare, hotShare string
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Reports stale CODEOWNERS entries and uncovered paths, exiting with code 5 if there are any",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if config.Anonymize {
+				fmt.Fprintf(os.Stderr, "--anonymize is not supported by codeowners\n")
+				os.Exit(2)
+			}
+			minLimit, err := parsePercent(minShare)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid min-share: %s\n", minShare)
+				os.Exit(2)
+			}
+			hotLimit, err := parsePercent(hotShare)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid hot-share: %s\n", hotShare)
+				os.Exit(2)
+			}
+
+			rules, err := readCodeowners(file, *config)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid CODEOWNERS: %v\n", err)
+				os.Exit(2)
+			}
+
+			report := collectStats(*config)
+			t := codeownersCheckTable(report, rules, minLimit, hotLimit, *config)
+
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				return writeTable(out, t, report.Metadata, config)
+			})
+			if len(t.Rows) > 0 {
+				os.Exit(exitCodeownersStale)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "CODEOWNERS file (default: .github/CODEOWNERS, CODEOWNERS or docs/CODEOWNERS at the revision)")
+	cmd.Flags().StringVar(&minShare, "min-share", "10", "Owners of an entry who wrote less than this share of its lines are stale")
+	cmd.Flags().StringVar(&hotShare, "hot-share", "5", "Report directories without owners holding at least this share of all lines")
+
+	return cmd
+}
+
+// readCodeowners parses the given file, or the CODEOWNERS of the revision.
+func readCodeowners(file string, config Config) ([]codeownersRule, error) {
+	var data []byte
+	if file != "" {
+		var err error
+		if data, err = os.ReadFile(file); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, location := range codeownersLocations {
+			cmd := gitCommand(config, "show", config.Revision+":"+location)
+			if out, err := cmd.Output(); err == nil {
+				data, file = out, location
+				break
+			}
+		}
+		if file == "" {
+			return nil, fmt.Errorf("no CODEOWNERS at %s", config.Revision)
+		}
+	}
+
+	var rules []codeownersRule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line, _, _ = strings.Cut(line, " #")
+		// Escaped spaces belong to the pattern.
+		fields := strings.Fields(strings.ReplaceAll(line, `\ `, "\x00"))
+		rule := codeownersRule{Pattern: strings.ReplaceAll(fields[0], "\x00", " "), Owners: fields[1:]}
+		if !doublestar.ValidatePattern(strings.TrimPrefix(rule.Pattern, "/")) {
+			return nil, fmt.Errorf("%s: invalid pattern %s", file, rule.Pattern)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// matchesCodeowners reports whether a CODEOWNERS pattern matches the file,
+// with gitignore semantics: patterns without an inner slash match at any
+// depth and directory patterns match everything below. As on GitHub, a
+// wildcard in the last element matches files only, so docs/* does not match
+// docs/a/b.md.
+func matchesCodeowners(pattern, file string) bool {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if !anchored {
+		pattern = "**/" + pattern
+	}
+
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	} else if !strings.ContainsAny(path.Base(pattern), "*?[") {
+		if matched, _ := doublestar.Match(pattern+"/**", file); matched {
+			return true
+		}
+	}
+	matched, _ := doublestar.Match(pattern, file)
+	return matched
+}
+
+// codeownersCheckTable assigns every file to the last matching rule and
+// reports listed owners below minLimit of their rule's lines, and the
+// topmost directories holding hotLimit of all lines without any owner.
+func codeownersCheckTable(report Report, rules []codeownersRule, minLimit, hotLimit float64, config Config) table {
+	t := table{Headers: []string{"Problem", "Path", "Owner", "Share%", "Lines"}}
+	add := func(problem, path, owner string, share float64, lines int) {
+		t.Rows = append(t.Rows, []string{problem, path, owner, formatValue(share), strconv.Itoa(lines)})
+		t.Items = append(t.Items, record{
+			{Key: "problem", Value: problem},
+			{Key: "path", Value: path},
+			{Key: "owner", Value: owner},
+			{Key: "share", Value: share},
+			{Key: "lines", Value: lines},
+		})
+	}
+
+	ruleLines := make([]map[string]int, len(rules))
+	var uncovered []FileStats
+	total := 0
+	for _, file := range report.Files {
+		for _, n := range file.Lines {
+			total += n
+		}
+		rule := -1
+		for i := range rules {
+			if matchesCodeowners(rules[i].Pattern, file.Path) {
+				rule = i
+			}
+		}
+		if rule < 0 || len(rules[rule].Owners) == 0 {
+			uncovered = append(uncovered, file)
+			continue
+		}
+		if ruleLines[rule] == nil {
+			ruleLines[rule] = make(map[string]int)
+		}
+		for actor, n := range file.Lines {
+			ruleLines[rule][actor] += n
+		}
+	}
+
+	for i, rule := range rules {
+		owners := ownerLines(ruleLines[i], report, config)
+		ruleTotal := 0
+		for _, n := range owners {
+			ruleTotal += n
+		}
+		if ruleTotal == 0 {
+			continue
+		}
+		for _, owner := range rule.Owners {
+			if share := percent(owners[owner], ruleTotal); share < minLimit {
+				add("stale", rule.Pattern, owner, share, owners[owner])
+			}
+		}
+	}
+
+	dirs := directoryLines(uncovered)
+	names := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		names = append(names, dir)
+	}
+	sort.Strings(names)
+	var reported []string
+	for _, dir := range names {
+		lines := 0
+		for _, n := range dirs[dir] {
+			lines += n
+		}
+		if lines == 0 || percent(lines, total) < hotLimit || underAny(dir, reported) {
+			continue
+		}
+		reported = append(reported, dir)
+		owner, _, share := topOwner(dirs[dir])
+		add("uncovered", dir, owner, share, lines)
+	}
+	return t
+}
+
+func underAny(dir string, parents []string) bool {
+	for _, p := range parents {
+		if p == "." || dir == p || strings.HasPrefix(dir, p+"/") {
+			return true
+		}
+	}
+	return false
+}
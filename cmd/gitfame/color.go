@@ -0,0 +1,101 @@
+//go:build !solution
+
+package main
+
+import (
+	"os"
+	"strconv"
+)
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiTop   = "\x1b[1;32m"
+	ansiShare = "\x1b[36m"
+	ansiDim   = "\x1b[2m"
+)
+
+// tableColors marks the rows of the main tabular table for --color: the top
+// contributor in bold green, the Percent cells in cyan and the rows of
+// actors with less than --dim-below of the lines dimmed. A nil tableColors
+// colors nothing.
+type tableColors struct {
+	dim     []bool
+	percent int
+	// top is the row of the actor with the most lines wherever --sort and
+	// --order-by put it, -1 without a Lines column.
+	top int
+}
+
+func newTableColors(t table, config Config) *tableColors {
+	if !colorEnabled(config) {
+		return nil
+	}
+
+	c := &tableColors{dim: make([]bool, len(t.Rows)), percent: -1, top: -1}
+	lines := -1
+	for j, header := range t.Headers {
+		switch header {
+		case "Lines":
+			lines = j
+		case "Percent":
+			c.percent = j
+		}
+	}
+	if lines < 0 {
+		return c
+	}
+
+	threshold, _ := parsePercent(config.DimBelow)
+	counts := make([]int, len(t.Rows))
+	total := 0
+	for i, row := range t.Rows {
+		if !t.nested(i) {
+			counts[i], _ = strconv.Atoi(row[lines])
+			total += counts[i]
+			if c.top < 0 || counts[i] > counts[c.top] {
+				c.top = i
+			}
+		}
+	}
+	for i := range t.Rows {
+		// Nested rows are dimmed with their actor.
+		if t.nested(i) {
+			c.dim[i] = i > 0 && c.dim[i-1]
+			continue
+		}
+		c.dim[i] = total > 0 && float64(counts[i])*100/float64(total) < threshold
+	}
+	return c
+}
+
+// code returns the escape sequence of a cell; row -1 is the header.
+func (c *tableColors) code(row, column int) string {
+	if c == nil || row < 0 {
+		return ""
+	}
+	switch {
+	case row == c.top:
+		return ansiTop
+	case c.dim[row]:
+		return ansiDim
+	case column == c.percent:
+		return ansiShare
+	}
+	return ""
+}
+
+// colorEnabled resolves --color: auto colors a terminal stdout unless
+// NO_COLOR is set or the report goes to --output files.
+func colorEnabled(config Config) bool {
+	switch config.Color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || len(config.Outputs) > 0 {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
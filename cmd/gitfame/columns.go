@@ -0,0 +1,174 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type column struct {
+	Header  string
+	Key     string
+	Numeric bool
+	Value   func(ActorStats) any
+}
+
+var baseColumns = []column{
+	{Header: "Name", Key: "name", Value: func(a ActorStats) any { return a.Name }},
+	{Header: "Lines", Key: "lines", Numeric: true, Value: func(a ActorStats) any { return a.Lines }},
+	{Header: "Commits", Key: "commits", Numeric: true, Value: func(a ActorStats) any { return a.Commits }},
+	{Header: "Files", Key: "files", Numeric: true, Value: func(a ActorStats) any { return a.Files }},
+}
+
+// selectableColumns are only shown when --columns asks for them.
+var selectableColumns = []column{
+	{Header: "Percent", Key: "percent", Numeric: true, Value: func(a ActorStats) any { return a.share }},
+	{Header: "Email", Key: "email", Value: func(a ActorStats) any { return primaryEmail(a) }},
+	{Header: "Code", Key: "code", Numeric: true, Value: func(a ActorStats) any { return a.code }},
+	{Header: "Comments", Key: "comments", Numeric: true, Value: func(a ActorStats) any { return a.comments }},
+	{Header: "Blank", Key: "blank", Numeric: true, Value: func(a ActorStats) any { return a.blank }},
+}
+
+// activeColumns returns the output columns enabled by the configuration, in
+// the order they are rendered by every format: the --columns keys if set.
+func activeColumns(config Config) []column {
+	columns, _ := selectColumns(config)
+	return columns
+}
+
+// selectColumns picks the --columns keys out of the enabled and selectable
+// columns and fails on keys that are not available.
+func selectColumns(config Config) ([]column, error) {
+	columns := enabledColumns(config)
+	if len(config.Columns) == 0 {
+		return columns, nil
+	}
+
+	byKey := make(map[string]column)
+	for _, c := range append(columns, selectableColumns...) {
+		byKey[c.Key] = c
+	}
+	selected := make([]column, 0, len(config.Columns))
+	for _, key := range config.Columns {
+		c, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("%s is unknown or not enabled by its flag", key)
+		}
+		selected = append(selected, c)
+	}
+	return selected, nil
+}
+
+func enabledColumns(config Config) []column {
+	columns := append([]column{}, baseColumns[0])
+	if config.GithubToken != "" || config.GitlabToken != "" {
+		columns = append(columns, loginColumns(config)...)
+	}
+	columns = append(columns, baseColumns[1:]...)
+	if config.Weighted {
+		columns = append(columns, weightedColumn)
+	}
+	if config.Estimate {
+		columns = append(columns, estimateColumns...)
+	}
+	columns = append(columns, velocityColumns(config)...)
+	if config.Sparkline > 0 {
+		columns = append(columns, trendColumn)
+	}
+	if config.Knowledge {
+		columns = append(columns, knowledgeColumn)
+	}
+	if config.Survival {
+		columns = append(columns, survivalColumn)
+	}
+	if config.Age {
+		columns = append(columns, ageColumns...)
+	}
+	if config.Activity {
+		columns = append(columns, activityColumns...)
+	}
+	if config.DepthProfile {
+		columns = append(columns, depthColumns...)
+	}
+	if config.TopAreas > 0 {
+		columns = append(columns, areasColumn)
+	}
+	if config.ShowRoles {
+		columns = append(columns, rolesColumn(config))
+	}
+	return columns
+}
+
+func columnHeaders(columns []column) []string {
+	headers := make([]string, 0, len(columns))
+	for _, c := range columns {
+		headers = append(headers, c.Header)
+	}
+	return headers
+}
+
+func columnValues(columns []column, actor ActorStats) []string {
+	values := make([]string, 0, len(columns))
+	for _, c := range columns {
+		values = append(values, formatValue(c.Value(actor)))
+	}
+	return values
+}
+
+func columnRecord(columns []column, actor ActorStats) record {
+	r := make(record, 0, len(columns))
+	for _, c := range columns {
+		r = append(r, field{Key: c.Key, Value: c.Value(actor)})
+	}
+	return r
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return strings.Join(v, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type field struct {
+	Key   string
+	Value any
+}
+
+// record is a JSON object that keeps its keys in column order.
+type record []field
+
+func (r record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
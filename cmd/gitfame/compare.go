@@ -0,0 +1,161 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const compareTop = 3
+
+func newCompareAuthorsCmd(config *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "compare-authors <name-or-email>...",
+		Short: "Shows a side-by-side breakdown of several authors",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			report := collectStats(*config)
+
+			actors := make([]string, 0, len(args))
+			for _, id := range args {
+				actor, ok := resolveActor(report.Actors, id)
+				if !ok {
+					fmt.Fprintf(os.Stderr, "Unknown author: %s\n", id)
+					os.Exit(2)
+				}
+				actors = append(actors, actor)
+			}
+			ids := args
+			if config.Anonymize {
+				report = anonymizeReport(report, *config)
+				ids = make([]string, len(actors))
+				for i, actor := range actors {
+					actors[i] = pseudonym(actor, *config)
+					ids[i] = actors[i]
+				}
+			}
+
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				return writeTable(out, compareTable(report, ids, actors, config), report.Metadata, config)
+			})
+		},
+	}
+}
+
+// resolveActor finds an actor by exact name or by any email they used.
+func resolveActor(stats map[string]ActorStats, id string) (string, bool) {
+	if _, ok := stats[id]; ok {
+		return id, true
+	}
+	for name, s := range stats {
+		for email := range s.emails {
+			if strings.EqualFold(email, id) {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+func primaryEmail(a ActorStats) string {
+	emails := topNames(a.emails, 1)
+	if len(emails) == 0 {
+		return ""
+	}
+	return emails[0]
+}
+
+func compareTable(report Report, ids, actors []string, config Config) table {
+	languages := make([]map[string]int, len(actors))
+	dirs := make([]map[string]int, len(actors))
+	sharedFiles := make([]int, len(actors))
+	sharedLines := make([]int, len(actors))
+	for i := range actors {
+		languages[i] = make(map[string]int)
+		dirs[i] = make(map[string]int)
+	}
+
+	for _, file := range report.Files {
+		owners := 0
+		for _, actor := range actors {
+			if _, ok := file.Lines[actor]; ok {
+				owners++
+			}
+		}
+
+		lang := languageOf(file.Path, config)
+		for i, actor := range actors {
+			n, ok := file.Lines[actor]
+			if !ok {
+				continue
+			}
+			if lang != "" {
+				languages[i][lang] += n
+			}
+			dirs[i][path.Dir(file.Path)] += n
+			if owners > 1 {
+				sharedFiles[i]++
+				sharedLines[i] += n
+			}
+		}
+	}
+
+	type metric struct {
+		key   string
+		value func(i int, a ActorStats) any
+	}
+	metrics := []metric{
+		{"Email", func(i int, a ActorStats) any { return primaryEmail(a) }},
+		{"Lines", func(i int, a ActorStats) any { return a.Lines }},
+		{"Commits", func(i int, a ActorStats) any { return a.Commits }},
+		{"Files", func(i int, a ActorStats) any { return a.Files }},
+		{"FirstCommit", func(i int, a ActorStats) any {
+			first, _ := activitySpan(a.authorTimes)
+			return formatDate(first)
+		}},
+		{"LastCommit", func(i int, a ActorStats) any {
+			_, last := activitySpan(a.authorTimes)
+			return formatDate(last)
+		}},
+		{"Languages", func(i int, a ActorStats) any { return topWithLines(languages[i], compareTop) }},
+		{"Directories", func(i int, a ActorStats) any { return topWithLines(dirs[i], compareTop) }},
+		{"SharedFiles", func(i int, a ActorStats) any { return sharedFiles[i] }},
+		{"SharedLines", func(i int, a ActorStats) any { return sharedLines[i] }},
+	}
+
+	t := table{
+		Headers: append([]string{"Metric"}, ids...),
+		Rows:    make([][]string, 0, len(metrics)),
+		Items:   make([]record, 0, len(metrics)),
+	}
+	for _, m := range metrics {
+		row := []string{m.key}
+		item := record{{Key: "metric", Value: m.key}}
+		for i, actor := range actors {
+			value := m.value(i, report.Actors[actor])
+			row = append(row, formatValue(value))
+			item = append(item, field{Key: ids[i], Value: value})
+		}
+		t.Rows = append(t.Rows, row)
+		t.Items = append(t.Items, item)
+	}
+	return t
+}
+
+// topWithLines renders the n keys with the most lines as "key (lines)".
+func topWithLines(lines map[string]int, n int) []string {
+	keys := topNames(lines, n)
+
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, key+" ("+strconv.Itoa(lines[key])+")")
+	}
+	return result
+}
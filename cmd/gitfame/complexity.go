@@ -0,0 +1,79 @@
+//go:build !solution
+
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+var weightedColumn = column{Header: "WeightedLines", Key: "weighted_lines", Numeric: true, Value: func(a ActorStats) any { return a.weighted }}
+
+// lineWeights returns the --weighted weight of every line of a Go file, first
+// line first: the cyclomatic complexity of the function declaration around
+// it, or 1 outside functions. Other files, and Go files that do not parse,
+// get nil and weigh 1 per line.
+func lineWeights(file string, lines []string, config Config) []int {
+	if !config.Weighted || !strings.HasSuffix(file, ".go") {
+		return nil
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, strings.Join(lines, "\n"), parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	weights := make([]int, len(lines))
+	for i := range weights {
+		weights[i] = 1
+	}
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		c := cyclomatic(fn)
+		start, end := fset.Position(fn.Pos()).Line, fset.Position(fn.End()).Line
+		for line := start; line <= end && line <= len(weights); line++ {
+			weights[line-1] = c
+		}
+	}
+	return weights
+}
+
+// weightAt returns the weight of the 1-based line n.
+func weightAt(weights []int, n int) int {
+	if n < 1 || n > len(weights) {
+		return 1
+	}
+	return weights[n-1]
+}
+
+// cyclomatic counts the decision points of a function plus one: branches,
+// loops, non-default cases and the && and || operators. Function literals
+// count towards the function they are in.
+func cyclomatic(fn *ast.FuncDecl) int {
+	c := 1
+	ast.Inspect(fn, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			c++
+		case *ast.CaseClause:
+			if n.List != nil {
+				c++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				c++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				c++
+			}
+		}
+		return true
+	})
+	return c
+}
@@ -0,0 +1,98 @@
+//go:build !solution
+
+package main
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// gini returns the Gini coefficient of the line counts: 0 when all actors own
+// the same number of lines, approaching 1 when one actor owns everything.
+func gini(lines map[string]int) float64 {
+	counts := make([]int, 0, len(lines))
+	total := 0
+	for _, n := range lines {
+		if n > 0 {
+			counts = append(counts, n)
+			total += n
+		}
+	}
+	if len(counts) < 2 {
+		return 0
+	}
+	sort.Ints(counts)
+
+	var weighted float64
+	for i, n := range counts {
+		weighted += float64(i+1) * float64(n)
+	}
+	k := float64(len(counts))
+	return round3(2*weighted/(k*float64(total)) - (k+1)/k)
+}
+
+// herfindahl returns the Herfindahl-Hirschman index of the line shares, from
+// 1/N for N equal owners to 1 for a single owner.
+func herfindahl(lines map[string]int) float64 {
+	total := 0
+	for _, n := range lines {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	var hhi float64
+	for _, n := range lines {
+		share := float64(n) / float64(total)
+		hhi += share * share
+	}
+	return round3(hhi)
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// concentrationSection reports how concentrated line ownership is in the
+// whole repository (".") and in every directory.
+func concentrationSection(files []FileStats) section {
+	dirs := directoryLines(files)
+	names := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		names = append(names, dir)
+	}
+	sort.Strings(names)
+
+	t := table{
+		Headers: []string{"Directory", "Authors", "Lines", "Gini", "HHI"},
+		Rows:    make([][]string, 0, len(names)),
+		Items:   make([]record, 0, len(names)),
+	}
+	for _, dir := range names {
+		lines := dirs[dir]
+		authors, total := 0, 0
+		for _, n := range lines {
+			if n > 0 {
+				authors++
+			}
+			total += n
+		}
+		g, h := gini(lines), herfindahl(lines)
+		t.Rows = append(t.Rows, []string{dir, strconv.Itoa(authors), strconv.Itoa(total), formatValue(g), formatValue(h)})
+		t.Items = append(t.Items, record{
+			{Key: "directory", Value: dir},
+			{Key: "authors", Value: authors},
+			{Key: "lines", Value: total},
+			{Key: "gini", Value: g},
+			{Key: "hhi", Value: h},
+		})
+	}
+
+	return section{
+		Name:  "concentration",
+		Title: "Concentration of line ownership",
+		Table: t,
+	}
+}
@@ -0,0 +1,110 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+
+	"gogitfame/configs"
+)
+
+// repoConfigName is the config file looked up in the repository root.
+const repoConfigName = ".gitfame.yaml"
+
+// configFilePaths returns the config files to load, most specific first:
+// --config alone, or .gitfame.yaml in the repository and the user config.
+func configFilePaths(config Config) []string {
+	if config.ConfigFile != "" {
+		return []string{config.ConfigFile}
+	}
+	if config.NoConfig {
+		return nil
+	}
+
+	var candidates []string
+	if len(config.Repositories) > 0 && !isRemoteRepository(config.Repositories[0]) {
+		candidates = append(candidates, filepath.Join(config.Repositories[0], repoConfigName))
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		candidates = append(candidates, filepath.Join(dir, "gitfame", "config.yaml"))
+	}
+
+	var paths []string
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// loadConfigFiles merges the config files into config.File and sets the
+// flags listed in the selected profile and in the defaults unless they were
+// given on the command line.
+func loadConfigFiles(config *Config, flags *pflag.FlagSet) error {
+	paths := configFilePaths(*config)
+
+	files := make([]configs.File, len(paths))
+	for i, path := range paths {
+		file, err := configs.LoadFile(path)
+		if err != nil {
+			return err
+		}
+		files[i] = file
+	}
+
+	if config.Profile != "" {
+		found := false
+		for i, file := range files {
+			if profile, ok := file.Profiles[config.Profile]; ok {
+				if err := applyDefaults(paths[i], profile, flags); err != nil {
+					return err
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("no profile %s in %v", config.Profile, paths)
+		}
+	}
+	for i, file := range files {
+		if err := applyDefaults(paths[i], file.Defaults, flags); err != nil {
+			return err
+		}
+	}
+
+	var merged configs.File
+	for i := len(files) - 1; i >= 0; i-- {
+		merged = configs.MergeFiles(merged, files[i])
+	}
+	config.File = merged
+	return nil
+}
+
+func applyDefaults(path string, defaults map[string]any, flags *pflag.FlagSet) error {
+	for name, value := range defaults {
+		flag := flags.Lookup(name)
+		if flag == nil || name == "config" || name == "no-config" || name == "profile" || name == "repository" {
+			return fmt.Errorf("%s: flag %s cannot be set in a config file", path, name)
+		}
+		if flag.Changed {
+			continue
+		}
+
+		values, ok := value.([]any)
+		if !ok {
+			values = []any{value}
+		}
+		for _, v := range values {
+			if err := flags.Set(name, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("%s: %s: %w", path, name, err)
+			}
+		}
+	}
+	return nil
+}
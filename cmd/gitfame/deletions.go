@@ -0,0 +1,234 @@
+//go:build !solution
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+type DeletionRow struct {
+	Author    string
+	DeletedBy string
+	Lines     int
+}
+
+// deletedHunks lists the removed line ranges of one file in one commit.
+type deletedHunks struct {
+	Commit string
+	Actor  string
+	File   string
+	Ranges [][2]int
+}
+
+func newDeletionsCmd(config *Config) *cobra.Command {
+	var from string
+
+	cmd := &cobra.Command{
+		Use:   "deletions",
+		Short: "Attributes deleted lines to their original authors and to who deleted them",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			revisionRange := config.Revision
+			if from != "" {
+				if !revisionExists(*config, from) {
+					fmt.Fprintf(os.Stderr, "Invalid revision: %s\n", from)
+					os.Exit(2)
+				}
+				revisionRange = from + ".." + config.Revision
+			}
+
+			rows := deletionStats(listDeletedHunks(*config, revisionRange), *config)
+
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				return writeTable(out, deletionsTable(rows, config), nil, config)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "Only consider commits after this revision (default: whole history)")
+
+	return cmd
+}
+
+func listDeletedHunks(config Config, revisionRange string) []deletedHunks {
+	actorFormat := "%an"
+	if config.UseCommitter {
+		actorFormat = "%cn"
+	}
+
+	cmd := gitCommand(config, "log", "-p", "--unified=0", "--no-renames", "--no-merges",
+		"--format=%x00%H%x00"+actorFormat, revisionRange)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git log: %v\n", err)
+		return nil
+	}
+
+	var result []deletedHunks
+	var current *deletedHunks
+	var commitHash, actor string
+	inHeader := false
+
+	flush := func() {
+		if current != nil && len(current.Ranges) > 0 && matchesFilters(current.File, config) {
+			result = append(result, *current)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(&out)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "\x00"):
+			flush()
+			parts := strings.SplitN(line[1:], "\x00", 2)
+			if len(parts) == 2 {
+				commitHash, actor = parts[0], parts[1]
+			}
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			inHeader = true
+		case inHeader && strings.HasPrefix(line, "--- "):
+			if path, ok := strings.CutPrefix(diffHeaderPath(line[len("--- "):]), "a/"); ok {
+				current = &deletedHunks{Commit: commitHash, Actor: actor, File: path}
+			}
+		case strings.HasPrefix(line, "@@ -"):
+			inHeader = false
+			if start, count, ok := parseOldRange(line); ok && count > 0 && current != nil {
+				current.Ranges = append(current.Ranges, [2]int{start, count})
+			}
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка чтения вывода команды git log: %v\n", err)
+		return nil
+	}
+
+	return result
+}
+
+// diffHeaderPath returns the path of a ---/+++ diff header. Git ends paths
+// with spaces with a tab and quotes paths with special characters, see
+// core.quotePath.
+func diffHeaderPath(s string) string {
+	s = strings.TrimSuffix(s, "\t")
+	if strings.HasPrefix(s, `"`) {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+	}
+	return s
+}
+
+// parseOldRange extracts the "-start,count" part of a hunk header.
+func parseOldRange(header string) (int, int, bool) {
+	fields := strings.Fields(header)
+	if len(fields) < 2 {
+		return 0, 0, false
+	}
+
+	oldRange := strings.TrimPrefix(fields[1], "-")
+	startStr, countStr, found := strings.Cut(oldRange, ",")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	count := 1
+	if found {
+		if count, err = strconv.Atoi(countStr); err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, count, true
+}
+
+// deletionStats blames the deleted ranges in the parent of every commit to
+// find who originally wrote the removed lines.
+func deletionStats(hunks []deletedHunks, config Config) []DeletionRow {
+	type key struct{ author, deletedBy string }
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	counts := make(map[key]int)
+	sem := make(chan struct{}, config.Jobs)
+
+	for _, h := range hunks {
+		wg.Add(1)
+		go func(h deletedHunks) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			args := []string{"blame", "--line-porcelain"}
+			for _, r := range h.Ranges {
+				args = append(args, "-L", fmt.Sprintf("%d,+%d", r[0], r[1]))
+			}
+			args = append(args, h.Commit+"^", "--", h.File)
+
+			cmd := gitCommand(config, args...)
+			var out bytes.Buffer
+			cmd.Stdout = &out
+			if err := cmd.Run(); err != nil {
+				return
+			}
+
+			forEachBlameGroup(out.String(), config, func(g blameGroup) {
+				mu.Lock()
+				counts[key{author: g.Actor, deletedBy: h.Actor}] += g.Lines
+				mu.Unlock()
+			})
+		}(h)
+	}
+	wg.Wait()
+
+	rows := make([]DeletionRow, 0, len(counts))
+	for k, n := range counts {
+		rows = append(rows, DeletionRow{Author: k.author, DeletedBy: k.deletedBy, Lines: n})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Lines != rows[j].Lines {
+			return rows[i].Lines > rows[j].Lines
+		}
+		if rows[i].Author != rows[j].Author {
+			return rows[i].Author < rows[j].Author
+		}
+		return rows[i].DeletedBy < rows[j].DeletedBy
+	})
+	return rows
+}
+
+func deletionsTable(rows []DeletionRow, config Config) table {
+	t := table{
+		Headers: []string{"Author", "DeletedBy", "Lines"},
+		Rows:    make([][]string, 0, len(rows)),
+		Items:   make([]record, 0, len(rows)),
+	}
+	for _, row := range rows {
+		author, deletedBy := anonymizedName(row.Author, config), anonymizedName(row.DeletedBy, config)
+		t.Rows = append(t.Rows, []string{author, deletedBy, strconv.Itoa(row.Lines)})
+		t.Items = append(t.Items, record{
+			{Key: "author", Value: author},
+			{Key: "deleted_by", Value: deletedBy},
+			{Key: "lines", Value: row.Lines},
+		})
+	}
+	return t
+}
@@ -0,0 +1,67 @@
+//go:build !solution
+
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+var depthColumns = []column{
+	{Header: "AvgDepth", Key: "avg_depth", Numeric: true, Value: func(a ActorStats) any { return a.avgDepth }},
+	{Header: "P90Depth", Key: "p90_depth", Numeric: true, Value: func(a ActorStats) any { return a.p90Depth }},
+}
+
+// computeDepth sets the average and 90th percentile directory depth of
+// every actor's lines; files in the repository root have depth 0.
+func computeDepth(stats map[string]ActorStats, files []FileStats, config Config) {
+	if !config.DepthProfile {
+		return
+	}
+
+	depths := make(map[string]map[int]int)
+	for _, file := range files {
+		depth := strings.Count(file.Path, "/")
+		for actor, n := range file.Lines {
+			if depths[actor] == nil {
+				depths[actor] = make(map[int]int)
+			}
+			depths[actor][depth] += n
+		}
+	}
+
+	for actor, s := range stats {
+		s.avgDepth, s.p90Depth = depthProfile(depths[actor])
+		stats[actor] = s
+	}
+}
+
+func depthProfile(lines map[int]int) (float64, int) {
+	depths := make([]int, 0, len(lines))
+	total := 0
+	for depth, n := range lines {
+		depths = append(depths, depth)
+		total += n
+	}
+	if total == 0 {
+		return 0, 0
+	}
+
+	sort.Ints(depths)
+
+	var sum float64
+	p90 := 0
+	rank := int(math.Ceil(float64(total) * 0.9))
+	seen := 0
+	for _, depth := range depths {
+		n := lines[depth]
+		sum += float64(depth * n)
+		if seen < rank && seen+n >= rank {
+			p90 = depth
+		}
+		seen += n
+	}
+
+	return math.Round(sum/float64(total)*10) / 10, p90
+}
@@ -0,0 +1,148 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-enry/go-enry/v2"
+)
+
+// detectContentLimit caps how much of a blob is read for classification.
+const detectContentLimit = 64 << 10
+
+// contentCache memoizes results derived from blob contents by revision and
+// path.
+type contentCache struct {
+	languages sync.Map
+	generated sync.Map
+}
+
+// languageOf returns the lower-cased language of a file, using the blob
+// content when --detect=content is set and the extensions map otherwise.
+// Extension-less files with an unknown name fall back to their shebang line,
+// and a linguist-language attribute overrides everything.
+func languageOf(file string, config Config) string {
+	if lang := attributeLanguage(file, config); lang != "" {
+		return lang
+	}
+	if config.Detect != "content" {
+		if lang := extensionLanguage(file, config); lang != "" || filepath.Ext(file) != "" {
+			return lang
+		}
+	}
+
+	key := config.Revision + ":" + file
+	if config.content != nil {
+		if lang, ok := config.content.languages.Load(key); ok {
+			return lang.(string)
+		}
+	}
+
+	var lang string
+	if config.Detect == "content" {
+		lang = strings.ToLower(enry.GetLanguage(path.Base(file), readBlobPrefix(file, config)))
+		if lang == "" {
+			lang = extensionLanguage(file, config)
+		}
+	} else {
+		lang = shebangLanguage(readBlobPrefix(file, config))
+	}
+
+	if config.content != nil {
+		config.content.languages.Store(key, lang)
+	}
+	return lang
+}
+
+func readBlobPrefix(file string, config Config) []byte {
+	if content, ok := blobPrefix(file, detectContentLimit, config); ok {
+		return content
+	}
+
+	cmd := gitCommand(config, "cat-file", "blob", config.Revision+":"+file)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil
+	}
+	if err := cmd.Start(); err != nil {
+		return nil
+	}
+
+	var content bytes.Buffer
+	_, _ = io.Copy(&content, io.LimitReader(stdout, detectContentLimit))
+	_, _ = io.Copy(io.Discard, stdout)
+	_ = cmd.Wait()
+
+	return content.Bytes()
+}
+
+func matchesDetectedLanguage(file string, config Config) bool {
+	lang := languageOf(file, config)
+	for _, allowedLang := range config.Languages {
+		allowedLang = strings.ToLower(allowedLang)
+		if lang == allowedLang && !excludedByLanguageOverride(file, allowedLang, config) {
+			return true
+		}
+	}
+	return false
+}
+
+// shebangInterpreters maps interpreter names to languages of the extensions map.
+var shebangInterpreters = map[string]string{
+	"sh":      "shell",
+	"bash":    "shell",
+	"dash":    "shell",
+	"ksh":     "shell",
+	"zsh":     "shell",
+	"csh":     "tcsh",
+	"tcsh":    "tcsh",
+	"fish":    "fish",
+	"python":  "python",
+	"node":    "javascript",
+	"nodejs":  "javascript",
+	"ruby":    "ruby",
+	"perl":    "perl",
+	"php":     "php",
+	"lua":     "lua",
+	"tclsh":   "tcl",
+	"wish":    "tcl",
+	"rscript": "r",
+	"awk":     "awk",
+	"gawk":    "awk",
+	"pwsh":    "powershell",
+	"elixir":  "elixir",
+	"groovy":  "groovy",
+	"scala":   "scala",
+}
+
+// shebangLanguage detects the language from a "#!" line such as
+// "#!/usr/bin/env python3" or "#!/bin/bash -e".
+func shebangLanguage(content []byte) string {
+	line, _, _ := bytes.Cut(content, []byte("\n"))
+	rest, ok := strings.CutPrefix(strings.TrimSpace(string(line)), "#!")
+	if !ok {
+		return ""
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) > 0 && path.Base(fields[0]) == "env" {
+		fields = fields[1:]
+		// Skip env options and variable assignments like -S or LC_ALL=C.
+		for len(fields) > 0 && (strings.HasPrefix(fields[0], "-") || strings.Contains(fields[0], "=")) {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// Versioned interpreters like python3.11 map to the plain name.
+	interpreter := strings.TrimRight(strings.ToLower(path.Base(fields[0])), "0123456789.")
+	return shebangInterpreters[interpreter]
+}
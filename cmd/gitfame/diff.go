@@ -0,0 +1,133 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+type DiffRow struct {
+	Name  string
+	From  ActorStats
+	To    ActorStats
+	Delta ActorStats
+}
+
+func newDiffCmd(config *Config) *cobra.Command {
+	var from, to string
+
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Shows per-author changes in statistics between two revisions",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if to == "" {
+				to = config.Revision
+			}
+			for _, revision := range []string{from, to} {
+				if !revisionExists(*config, revision) {
+					fmt.Fprintf(os.Stderr, "Invalid revision: %s\n", revision)
+					os.Exit(2)
+				}
+			}
+
+			fromConfig, toConfig := *config, *config
+			fromConfig.Revision, toConfig.Revision = from, to
+
+			fromReport := collectStats(fromConfig)
+			toReport := collectStats(toConfig)
+			if config.Anonymize {
+				fromReport, toReport = anonymizeReport(fromReport, *config), anonymizeReport(toReport, *config)
+			}
+
+			stats := diffStats(fromReport.Actors, toReport.Actors, *config)
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				return writeTable(out, diffTable(stats, config.Format), toReport.Metadata, config)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "Base revision")
+	cmd.Flags().StringVar(&to, "to", "", "Target revision (default: --revision)")
+	_ = cmd.MarkFlagRequired("from")
+
+	return cmd
+}
+
+// diffStats pairs up actors of both snapshots; actors missing on one side
+// are compared against zero stats. Rows are ordered by their deltas.
+func diffStats(fromStats, toStats map[string]ActorStats, config Config) []DiffRow {
+	names := make(map[string]struct{})
+	for name := range fromStats {
+		names[name] = struct{}{}
+	}
+	for name := range toStats {
+		names[name] = struct{}{}
+	}
+
+	deltas := make([]ActorStats, 0, len(names))
+	rows := make(map[string]DiffRow, len(names))
+	for name := range names {
+		from, to := fromStats[name], toStats[name]
+		delta := ActorStats{
+			Name:    name,
+			Lines:   to.Lines - from.Lines,
+			Commits: to.Commits - from.Commits,
+			Files:   to.Files - from.Files,
+		}
+		deltas = append(deltas, delta)
+		rows[name] = DiffRow{Name: name, From: from, To: to, Delta: delta}
+	}
+
+	sortByConfig(deltas, config)
+
+	result := make([]DiffRow, 0, len(deltas))
+	for _, delta := range deltas {
+		result = append(result, rows[delta.Name])
+	}
+	return result
+}
+
+func diffTable(rows []DiffRow, format string) table {
+	t := table{
+		Headers: []string{"Name", "Lines", "+/-Lines", "Commits", "+/-Commits", "Files", "+/-Files"},
+		Rows:    make([][]string, 0, len(rows)),
+		Items:   make([]record, 0, len(rows)),
+	}
+
+	delta := strconv.Itoa
+	if format == "tabular" {
+		delta = signed
+	}
+
+	for _, row := range rows {
+		t.Rows = append(t.Rows, []string{
+			row.Name,
+			strconv.Itoa(row.To.Lines), delta(row.Delta.Lines),
+			strconv.Itoa(row.To.Commits), delta(row.Delta.Commits),
+			strconv.Itoa(row.To.Files), delta(row.Delta.Files),
+		})
+		t.Items = append(t.Items, record{
+			{Key: "name", Value: row.Name},
+			{Key: "lines", Value: row.To.Lines},
+			{Key: "lines_delta", Value: row.Delta.Lines},
+			{Key: "commits", Value: row.To.Commits},
+			{Key: "commits_delta", Value: row.Delta.Commits},
+			{Key: "files", Value: row.To.Files},
+			{Key: "files_delta", Value: row.Delta.Files},
+		})
+	}
+	return t
+}
+
+func signed(n int) string {
+	if n > 0 {
+		return "+" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
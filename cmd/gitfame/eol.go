@@ -0,0 +1,84 @@
+//go:build !solution
+
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// blameContentLines counts the lines of one blamed line's content, treating a
+// lone CR as a line break of its own. git splits lines on LF only, so a
+// classic-Mac file is reported as a single line.
+func blameContentLines(content string) int {
+	return strings.Count(strings.TrimSuffix(content, "\r"), "\r") + 1
+}
+
+// blameContent returns the content of the lines of `git blame
+// --line-porcelain` output, in file order.
+func blameContent(out string) []string {
+	var lines []string
+	for _, line := range strings.Split(out, "\n") {
+		if content, ok := strings.CutPrefix(line, "\t"); ok {
+			lines = append(lines, content)
+		}
+	}
+	return lines
+}
+
+// eolStyleOf classifies the line endings of the blamed lines of a file: "cr"
+// when git saw the whole file as one CR-separated line, "mixed" when some
+// lines contain lone CRs and "" when no normalization was needed.
+func eolStyleOf(lines []string) string {
+	blamed, normalized := len(lines), 0
+	for _, content := range lines {
+		if blameContentLines(content) > 1 {
+			normalized++
+		}
+	}
+
+	switch {
+	case normalized == 0:
+		return ""
+	case blamed == 1:
+		return "cr"
+	default:
+		return "mixed"
+	}
+}
+
+func eolSection(files []FileStats, config Config) section {
+	var affected []FileStats
+	for _, file := range files {
+		if file.EOL != "" {
+			affected = append(affected, file)
+		}
+	}
+	sort.Slice(affected, func(i, j int) bool { return affected[i].Path < affected[j].Path })
+
+	t := table{
+		Headers: []string{"Path", "EOL", "Lines"},
+		Rows:    make([][]string, 0, len(affected)),
+		Items:   make([]record, 0, len(affected)),
+	}
+	for _, file := range affected {
+		total := 0
+		for _, n := range file.Lines {
+			total += n
+		}
+		path := formatPath(file.Path, config)
+		t.Rows = append(t.Rows, []string{path, file.EOL, strconv.Itoa(total)})
+		t.Items = append(t.Items, record{
+			{Key: "path", Value: path},
+			{Key: "eol", Value: file.EOL},
+			{Key: "lines", Value: total},
+		})
+	}
+
+	return section{
+		Name:  "eol",
+		Title: "Files with CR-only or mixed line endings",
+		Table: t,
+	}
+}
@@ -0,0 +1,215 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math"
+	"sort"
+	"strings"
+)
+
+// estimateZ is the normal quantile of the reported 95% confidence intervals.
+const estimateZ = 1.96
+
+// studentT are the quantiles of the 95% intervals of Student's t
+// distribution by degrees of freedom, used in their place for small samples.
+var studentT = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+func estimateQuantile(df float64) float64 {
+	if df < float64(len(studentT)+1) {
+		return studentT[max(int(df), 1)-1]
+	}
+	return estimateZ
+}
+
+var estimateColumns = []column{
+	{Header: "LinesLow", Key: "lines_low", Numeric: true, Value: func(a ActorStats) any { return a.linesLow }},
+	{Header: "LinesHigh", Key: "lines_high", Numeric: true, Value: func(a ActorStats) any { return a.linesHigh }},
+}
+
+// stratum is a group of similar files of which only Sample is blamed.
+type stratum struct {
+	Population int
+	Sample     []string
+	Unsampled  []string
+}
+
+// stratumKey groups files by top-level directory and size class, so that
+// the sample covers every part of the tree.
+func stratumKey(file string, size int64) string {
+	dir, _, found := strings.Cut(file, "/")
+	if !found {
+		dir = "."
+	}
+
+	class := "small"
+	switch {
+	case size >= 64<<10:
+		class = "large"
+	case size >= 4<<10:
+		class = "medium"
+	}
+	return dir + ":" + class
+}
+
+// sampleStrata picks the given percentage of every stratum, at least two
+// files each so that the variance of every stratum can be estimated. Files are ordered by a hash of their path, so runs are reproducible.
+func sampleStrata(files []string, share float64, config Config) map[string]*stratum {
+	sizes := fileSizes(config)
+	members := make(map[string][]string)
+	for _, file := range files {
+		key := stratumKey(file, sizes[file])
+		members[key] = append(members[key], file)
+	}
+
+	strata := make(map[string]*stratum, len(members))
+	for key, files := range members {
+		sort.Slice(files, func(i, j int) bool { return pathHash(files[i]) < pathHash(files[j]) })
+		n := int(math.Ceil(float64(len(files)) * share / 100))
+		n = min(max(n, 2), len(files))
+		strata[key] = &stratum{Population: len(files), Sample: files[:n], Unsampled: files[n:]}
+	}
+	return strata
+}
+
+func pathHash(file string) string {
+	sum := sha256.Sum256([]byte(file))
+	return string(sum[:])
+}
+
+// estimateStats blames a stratified sample of the files and extrapolates the
+// lines and files of every actor, with a 95% confidence interval for lines.
+func estimateStats(files chan string, config Config) (map[string]ActorStats, []FileStats) {
+	var all []string
+	for file := range files {
+		all = append(all, file)
+	}
+	share, _ := parsePercent(config.SampleFiles)
+	strata := sampleStrata(all, share, config)
+
+	sampled := make(chan string, len(all))
+	for _, s := range strata {
+		for _, file := range s.Sample {
+			sampled <- file
+		}
+	}
+	close(sampled)
+
+	stats, fileStats := aggregateStats(sampled, config)
+
+	lines := make(map[string]map[string]int, len(fileStats))
+	// The lines of the sample and of the unsampled files bound the lines of
+	// every actor.
+	blamed := 0
+	for _, file := range fileStats {
+		lines[file.Path] = file.Lines
+		for _, n := range file.Lines {
+			blamed += n
+		}
+	}
+
+	unsampled := make(map[*stratum]float64, len(strata))
+	totalLines := float64(blamed)
+	for _, s := range strata {
+		unsampled[s] = float64(countLines(s.Unsampled, config))
+		totalLines += unsampled[s]
+	}
+
+	for actor, a := range stats {
+		// low and high add up the stratum totals, widened for the strata
+		// that say nothing about the unsampled files.
+		var total, variance, low, high, filesTotal float64
+		// dfTerms is the denominator of the Welch-Satterthwaite degrees of
+		// freedom.
+		var dfTerms float64
+		for _, s := range strata {
+			actorLines := make([]float64, 0, len(s.Sample))
+			actorFiles := make([]float64, 0, len(s.Sample))
+			for _, file := range s.Sample {
+				n := lines[file][actor]
+				actorLines = append(actorLines, float64(n))
+				actorFiles = append(actorFiles, float64(min(n, 1)))
+			}
+
+			t, v := stratumEstimate(actorLines, s.Population)
+			total += t
+			if v == 0 && len(s.Unsampled) > 0 {
+				// The actor owns the same lines in every sampled file, most
+				// often none, and anything from none to all of the lines of
+				// the unsampled ones.
+				var observed float64
+				for _, n := range actorLines {
+					observed += n
+				}
+				low += observed
+				high += observed + unsampled[s]
+			} else {
+				variance += v
+				if v > 0 {
+					dfTerms += v * v / float64(len(s.Sample)-1)
+				}
+				low += t
+				high += t
+			}
+			f, _ := stratumEstimate(actorFiles, s.Population)
+			filesTotal += f
+		}
+
+		margin := 0.0
+		if variance > 0 {
+			margin = estimateQuantile(variance*variance/dfTerms) * math.Sqrt(variance)
+		}
+		// The blamed lines are a hard lower bound.
+		a.linesLow = max(a.Lines, int(math.Round(low-margin)))
+		a.linesHigh = int(math.Round(min(high+margin, totalLines)))
+		a.Lines = int(math.Round(min(total, totalLines)))
+		a.Files = min(max(a.Files, int(math.Round(filesTotal))), len(all))
+		stats[actor] = a
+	}
+	return stats, fileStats
+}
+
+// countLines counts the lines of the files at the revision without blaming
+// them.
+func countLines(files []string, config Config) int {
+	total := 0
+	for _, file := range files {
+		content, _ := blobPrefix(file, math.MaxInt64, config)
+		total += bytes.Count(content, []byte("\n"))
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			total++
+		}
+	}
+	return total
+}
+
+// stratumEstimate returns the estimated stratum total and its variance with
+// the finite population correction.
+func stratumEstimate(sample []float64, population int) (float64, float64) {
+	n := float64(len(sample))
+	if n == 0 {
+		return 0, 0
+	}
+	N := float64(population)
+
+	var sum float64
+	for _, y := range sample {
+		sum += y
+	}
+	mean := sum / n
+	if n < 2 {
+		return N * mean, 0
+	}
+
+	var squares float64
+	for _, y := range sample {
+		squares += (y - mean) * (y - mean)
+	}
+	return N * mean, N * N * (1 - n/N) * squares / (n - 1) / n
+}
@@ -0,0 +1,154 @@
+//go:build !solution
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type numstatEntry struct {
+	Commit  string
+	Actor   string
+	File    string
+	Added   int
+	Deleted int
+	// Binary files are reported as "-\t-" and carry no line counts.
+	Binary bool
+}
+
+// forEachNumstat walks `git log --numstat` of the revision and calls fn for
+// every changed file of every non-merge commit.
+func forEachNumstat(config Config, fn func(e numstatEntry)) bool {
+	actorFormat := "%an"
+	if config.UseCommitter {
+		actorFormat = "%cn"
+	}
+
+	cmd := gitCommand(config, "log", "--numstat", "--no-renames",
+		"--format=%x00%H%x00"+actorFormat, config.Revision)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git log: %v\n", err)
+		return false
+	}
+
+	var commitHash, actor string
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\x00") {
+			parts := strings.SplitN(line[1:], "\x00", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			commitHash, actor = parts[0], parts[1]
+			if isImported(commitHash, config) {
+				actor = importedActor
+			}
+			continue
+		}
+
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		entry := numstatEntry{Commit: commitHash, Actor: actor, File: parts[2]}
+		added, errAdded := strconv.Atoi(parts[0])
+		deleted, errDeleted := strconv.Atoi(parts[1])
+		if errAdded != nil || errDeleted != nil {
+			entry.Binary = true
+		} else {
+			entry.Added, entry.Deleted = added, deleted
+		}
+		fn(entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка чтения вывода команды git log: %v\n", err)
+		return false
+	}
+
+	return true
+}
+
+// fastStats approximates blame results from a single `git log --numstat` pass:
+// every author is credited with the lines they added minus the lines they
+// deleted in each file, so rewrites of other people's code are not tracked.
+func fastStats(files chan string, config Config) (map[string]ActorStats, []FileStats) {
+	wanted := make(map[string]struct{})
+	for file := range files {
+		wanted[file] = struct{}{}
+	}
+
+	netLines := make(map[string]map[string]int)
+	commitsSets := make(map[string]map[string]struct{})
+	fileCommits := make(map[string]map[string]map[string]struct{})
+
+	ok := forEachNumstat(config, func(e numstatEntry) {
+		if _, ok := wanted[e.File]; !ok {
+			return
+		}
+
+		if _, ok := commitsSets[e.Actor]; !ok {
+			commitsSets[e.Actor] = make(map[string]struct{})
+			netLines[e.Actor] = make(map[string]int)
+		}
+		commitsSets[e.Actor][e.Commit] = struct{}{}
+		if fileCommits[e.File] == nil {
+			fileCommits[e.File] = make(map[string]map[string]struct{})
+		}
+		if fileCommits[e.File][e.Actor] == nil {
+			fileCommits[e.File][e.Actor] = make(map[string]struct{})
+		}
+		fileCommits[e.File][e.Actor][e.Commit] = struct{}{}
+
+		if !e.Binary {
+			netLines[e.Actor][e.File] += e.Added - e.Deleted
+		}
+	})
+	if !ok {
+		return nil, nil
+	}
+
+	fileLines := make(map[string]map[string]int)
+	actorStats := make(map[string]ActorStats)
+	for actor, commitsSet := range commitsSets {
+		stats := ActorStats{
+			Name:       actor,
+			commitsSet: commitsSet,
+			Commits:    len(commitsSet),
+		}
+		for file, n := range netLines[actor] {
+			if n > 0 {
+				stats.Lines += n
+				stats.Files++
+				if fileLines[file] == nil {
+					fileLines[file] = make(map[string]int)
+				}
+				fileLines[file][actor] = n
+			}
+		}
+		actorStats[actor] = stats
+	}
+
+	fileStats := make([]FileStats, 0, len(fileLines))
+	for file, lines := range fileLines {
+		commits := make(map[string]map[string]struct{}, len(lines))
+		for actor := range lines {
+			commits[actor] = fileCommits[file][actor]
+		}
+		fileStats = append(fileStats, FileStats{Path: file, Lines: lines, Commits: commits})
+	}
+	sort.Slice(fileStats, func(i, j int) bool { return fileStats[i].Path < fileStats[j].Path })
+
+	return actorStats, fileStats
+}
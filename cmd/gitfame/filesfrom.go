@@ -0,0 +1,41 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// readFilesFrom reads the paths for --files-from from the file, or stdin for
+// "-". Paths are NUL-separated when the input has a NUL, as with `git diff
+// --name-only -z`, and newline-separated otherwise.
+func readFilesFrom(name string) (map[string]bool, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sep := []byte("\n")
+	if bytes.IndexByte(data, 0) >= 0 {
+		sep = []byte{0}
+	}
+	files := make(map[string]bool)
+	for _, entry := range bytes.Split(data, sep) {
+		file := strings.TrimSuffix(string(entry), "\r")
+		if file == "" {
+			continue
+		}
+		files[strings.TrimPrefix(path.Clean(file), "./")] = true
+	}
+	return files, nil
+}
@@ -0,0 +1,165 @@
+//go:build !solution
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var githubRemoteRegexp = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(\.git)?/?$`)
+
+// forgeAuthor is the identity a squash-merge commit is credited to.
+type forgeAuthor struct {
+	Name  string
+	Email string
+}
+
+// githubClient calls the GitHub API at GITHUB_API_URL (api.github.com by
+// default).
+type githubClient struct {
+	api    string
+	token  string
+	client *http.Client
+}
+
+func newGithubClient(token string) githubClient {
+	api := os.Getenv("GITHUB_API_URL")
+	if api == "" {
+		api = "https://api.github.com"
+	}
+	return githubClient{
+		api:    strings.TrimSuffix(api, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// githubAttribution maps squash-merge commits to the main author of the pull
+// request they came from, asking the GitHub API once per commit.
+type githubAttribution struct {
+	githubClient
+	slug string
+
+	mu      sync.Mutex
+	authors map[string]*forgeAuthor
+	failed  bool
+}
+
+func newGithubAttribution(config Config) (*githubAttribution, error) {
+	slug, err := githubSlug(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &githubAttribution{
+		githubClient: newGithubClient(os.Getenv("GITHUB_TOKEN")),
+		slug:         slug,
+		authors:      make(map[string]*forgeAuthor),
+	}, nil
+}
+
+// githubSlug returns the owner/name of the GitHub repository origin points to.
+func githubSlug(config Config) (string, error) {
+	cmd := gitCommand(config, "remote", "get-url", "origin")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git remote get-url origin: %w", err)
+	}
+
+	m := githubRemoteRegexp.FindStringSubmatch(strings.TrimSpace(string(out)))
+	if m == nil {
+		return "", fmt.Errorf("origin is not a GitHub repository: %s", strings.TrimSpace(string(out)))
+	}
+	return m[1] + "/" + m[2], nil
+}
+
+// author returns the pull request author a commit should be credited to, or
+// false when the commit is not a squash merge of somebody else's work.
+func (g *githubAttribution) author(commit, actor string) (forgeAuthor, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	a, ok := g.authors[commit]
+	if !ok {
+		if g.failed {
+			return forgeAuthor{}, false
+		}
+		var err error
+		if a, err = g.lookup(commit); err != nil {
+			fmt.Fprintf(os.Stderr, "GitHub attribution disabled: %v\n", err)
+			g.failed = true
+			return forgeAuthor{}, false
+		}
+		g.authors[commit] = a
+	}
+
+	if a == nil || a.Name == actor {
+		return forgeAuthor{}, false
+	}
+	return *a, true
+}
+
+func (g *githubAttribution) lookup(commit string) (*forgeAuthor, error) {
+	var pulls []struct {
+		Number         int    `json:"number"`
+		MergeCommitSHA string `json:"merge_commit_sha"`
+	}
+	if err := g.get(fmt.Sprintf("/repos/%s/commits/%s/pulls", g.slug, commit), &pulls); err != nil {
+		return nil, err
+	}
+
+	for _, pull := range pulls {
+		if pull.MergeCommitSHA != commit {
+			continue
+		}
+
+		var commits []struct {
+			Commit struct {
+				Author forgeAuthor `json:"author"`
+			} `json:"commit"`
+		}
+		if err := g.get(fmt.Sprintf("/repos/%s/pulls/%d/commits?per_page=100", g.slug, pull.Number), &commits); err != nil {
+			return nil, err
+		}
+
+		counts := make(map[string]int)
+		emails := make(map[string]string)
+		for _, c := range commits {
+			counts[c.Commit.Author.Name]++
+			emails[c.Commit.Author.Name] = c.Commit.Author.Email
+		}
+		if names := topNames(counts, 1); len(names) > 0 {
+			return &forgeAuthor{Name: names[0], Email: emails[names[0]]}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (g *githubClient) get(path string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, g.api+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,255 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// symbolSpan is a function, method or class with the lines it spans.
+type symbolSpan struct {
+	Name       string
+	Start, End int
+}
+
+// functionRow is the ownership of one function or method.
+type functionRow struct {
+	File     string
+	Function string
+	Line     int
+	Lines    int
+	Owner    string
+	Owned    int
+	Authors  int
+}
+
+func newFunctionsCmd(config *Config) *cobra.Command {
+	var paths []string
+
+	cmd := &cobra.Command{
+		Use:   "functions",
+		Short: "Attributes the lines of every function, method and class to their authors",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			rows := functionOwnership(*config, paths)
+
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				return writeTable(out, functionsTable(rows, config), nil, config)
+			})
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&paths, "path", nil, "Only analyze these directories, like Go packages: a directory, or dir/... with its subdirectories (repeatable)")
+
+	return cmd
+}
+
+// functionOwnership blames the files of the packages and counts the lines
+// of every function declaration, from its first line to its last, by actor.
+// Go files are parsed with go/ast, files of the languages in symbolGrammars
+// with tree-sitter. Go files that do not parse are skipped.
+func functionOwnership(config Config, paths []string) []functionRow {
+	var files []string
+	for _, file := range analyzedFiles(config) {
+		if matchesPackages(file, paths) {
+			files = append(files, file)
+		}
+	}
+
+	var mu sync.Mutex
+	var rows []functionRow
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, config.Jobs)
+	for _, file := range files {
+		wg.Add(1)
+		go func(file string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			lang := languageOf(file, config)
+			if !strings.HasSuffix(file, ".go") && !hasSymbolGrammar(lang) {
+				return
+			}
+
+			var out bytes.Buffer
+			cmd := gitCommand(config, "blame", "--line-porcelain", file, config.Revision)
+			cmd.Stdout = &out
+			if err := cmd.Run(); err != nil {
+				return
+			}
+
+			lines := blameContent(out.String())
+			src := strings.Join(lines, "\n")
+			var symbols []symbolSpan
+			if strings.HasSuffix(file, ".go") {
+				var err error
+				if symbols, err = goSymbols(file, src); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+					return
+				}
+			} else {
+				symbols = parseSymbols(lang, []byte(src))
+			}
+
+			kinds := fileKinds(file, lines, config)
+			owners := make([]string, len(lines)+1)
+			forEachBlameGroup(out.String(), config, func(g blameGroup) {
+				for i := 0; i < g.Count && g.FinalLine+i < len(owners); i++ {
+					owners[g.FinalLine+i] = g.Actor
+				}
+			})
+
+			fileRows := make([]functionRow, 0, len(symbols))
+			for _, s := range symbols {
+				owned := make(map[string]int)
+				for line := s.Start; line <= s.End && line < len(owners); line++ {
+					if creditedLine(kinds.at(line), config) {
+						owned[owners[line]]++
+					}
+				}
+				fileRows = append(fileRows, functionRowOf(file, s.Name, s.Start, owned))
+			}
+
+			mu.Lock()
+			rows = append(rows, fileRows...)
+			mu.Unlock()
+		}(file)
+	}
+	wg.Wait()
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].File != rows[j].File {
+			return rows[i].File < rows[j].File
+		}
+		return rows[i].Line < rows[j].Line
+	})
+	return rows
+}
+
+// goSymbols lists the function and method declarations of a Go file.
+func goSymbols(file, src string) ([]symbolSpan, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []symbolSpan
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			symbols = append(symbols, symbolSpan{Name: funcName(fn), Start: fset.Position(fn.Pos()).Line, End: fset.Position(fn.End()).Line})
+		}
+	}
+	return symbols, nil
+}
+
+// matchesPackages reports whether the file belongs to one of the packages,
+// given like for the go tool: a directory or dir/... for the directory and
+// its subdirectories. No packages match all files.
+func matchesPackages(file string, packages []string) bool {
+	if len(packages) == 0 {
+		return true
+	}
+	dir := path.Dir(file)
+	for _, p := range packages {
+		p = strings.TrimSuffix(strings.TrimPrefix(p, "./"), "/")
+		if p == "..." {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(p, "/..."); ok {
+			if dir == prefix || strings.HasPrefix(dir, prefix+"/") {
+				return true
+			}
+		} else if dir == p || p == "." && dir == "." {
+			return true
+		}
+	}
+	return false
+}
+
+// creditedLine reports whether a line of the kind is counted, like
+// classifyGroup does for files.
+func creditedLine(kind lineKind, config Config) bool {
+	if kind == licenseLine {
+		return false
+	}
+	return config.Count != "code" || kind == codeLine
+}
+
+// funcName returns the name of a function, or of a method with its receiver
+// type like (*T).M or T.M.
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	recv := fn.Recv.List[0].Type
+	star, ok := recv.(*ast.StarExpr)
+	if ok {
+		recv = star.X
+	}
+	switch t := recv.(type) {
+	case *ast.IndexExpr:
+		recv = t.X
+	case *ast.IndexListExpr:
+		recv = t.X
+	}
+
+	name := "?"
+	if ident, isIdent := recv.(*ast.Ident); isIdent {
+		name = ident.Name
+	}
+	if ok {
+		return "(*" + name + ")." + fn.Name.Name
+	}
+	return name + "." + fn.Name.Name
+}
+
+// functionRowOf names the actor with the most lines, the first by name on
+// ties, as the owner.
+func functionRowOf(file, name string, line int, owned map[string]int) functionRow {
+	row := functionRow{File: file, Function: name, Line: line, Authors: len(owned)}
+	for actor, n := range owned {
+		row.Lines += n
+		if n > row.Owned || n == row.Owned && actor < row.Owner {
+			row.Owner, row.Owned = actor, n
+		}
+	}
+	return row
+}
+
+func functionsTable(rows []functionRow, config Config) table {
+	t := table{
+		Headers: []string{"File", "Function", "Lines", "Owner", "Share%", "Authors"},
+		Rows:    make([][]string, 0, len(rows)),
+		Items:   make([]record, 0, len(rows)),
+	}
+	for _, r := range rows {
+		share := percent(r.Owned, r.Lines)
+		file, owner := formatPath(r.File, config), anonymizedName(r.Owner, config)
+		t.Rows = append(t.Rows, []string{file, r.Function, strconv.Itoa(r.Lines), owner, formatValue(share), strconv.Itoa(r.Authors)})
+		t.Items = append(t.Items, record{
+			{Key: "file", Value: file},
+			{Key: "function", Value: r.Function},
+			{Key: "lines", Value: r.Lines},
+			{Key: "owner", Value: owner},
+			{Key: "share", Value: share},
+			{Key: "authors", Value: r.Authors},
+		})
+	}
+	return t
+}
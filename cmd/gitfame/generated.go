@@ -0,0 +1,53 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// generatedHeaderLines is how many leading lines are searched for markers.
+const generatedHeaderLines = 20
+
+var generatedMarkers = []*regexp.Regexp{
+	regexp.MustCompile(`\bCode generated by\b`),
+	regexp.MustCompile(`\bDO NOT EDIT\b`),
+	regexp.MustCompile(`@generated\b`),
+	regexp.MustCompile(`(?i)\bauto-?generated\b`),
+	regexp.MustCompile(`Generated by the protocol buffer compiler`),
+}
+
+// isGenerated reports whether the first lines of the file at the revision
+// contain a generated-code marker.
+func isGenerated(file string, config Config) bool {
+	key := config.Revision + ":" + file
+	if config.content != nil {
+		if generated, ok := config.content.generated.Load(key); ok {
+			return generated.(bool)
+		}
+	}
+
+	generated := hasGeneratedMarker(readBlobPrefix(file, config))
+
+	if config.content != nil {
+		config.content.generated.Store(key, generated)
+	}
+	return generated
+}
+
+func hasGeneratedMarker(content []byte) bool {
+	lines := bytes.SplitN(content, []byte("\n"), generatedHeaderLines+1)
+	if len(lines) > generatedHeaderLines {
+		lines = lines[:generatedHeaderLines]
+	}
+
+	for _, line := range lines {
+		for _, marker := range generatedMarkers {
+			if marker.Match(line) {
+				return true
+			}
+		}
+	}
+	return false
+}
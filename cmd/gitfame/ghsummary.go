@@ -0,0 +1,58 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// writeMarkdownRows renders the table as a GitHub flavored markdown table.
+func writeMarkdownRows(out io.Writer, t table) {
+	fmt.Fprintf(out, "| %s |\n", strings.Join(markdownCells(t.Headers), " | "))
+	fmt.Fprintf(out, "|%s\n", strings.Repeat(" --- |", len(t.Headers)))
+	for _, row := range t.Rows {
+		fmt.Fprintf(out, "| %s |\n", strings.Join(markdownCells(row), " | "))
+	}
+}
+
+func markdownCells(cells []string) []string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		cell = strings.ReplaceAll(cell, "|", "\\|")
+		escaped[i] = strings.Join(strings.Fields(cell), " ")
+	}
+	return escaped
+}
+
+// writeStepSummary appends the report to the job summary of the GitHub
+// Actions step, or writes it to stdout outside of Actions.
+func writeStepSummary(buf *bytes.Buffer) {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		writeOutput(buf)
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err == nil {
+		_, err = buf.WriteTo(f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Writing error: %s", err)
+		os.Exit(1)
+	}
+}
+
+// annotate emits an error annotation of the workflow run.
+func annotate(title, message string) {
+	property := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+	data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	fmt.Printf("::error title=%s::%s\n", property.Replace(title), data.Replace(message))
+}
@@ -0,0 +1,68 @@
+//go:build !solution
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// pinnedGitConfig fixes the settings that change blame, diff and log output,
+// so that user and system configs cannot affect the report.
+var pinnedGitConfig = []string{
+	"-c", "diff.algorithm=myers",
+	"-c", "diff.renames=false",
+	"-c", "diff.renameLimit=1000",
+	"-c", "blame.ignoreRevsFile=",
+	"-c", "log.showSignature=false",
+	"-c", "core.quotePath=false",
+	"-c", "color.ui=never",
+}
+
+// gitCommand prepares a git command that runs in the repository with a
+// sanitized environment: GIT_* variables are dropped and, unless
+// --use-user-gitconfig is set, the global and system configs are ignored.
+func gitCommand(config Config, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", append(append([]string{"-C", config.Repository}, pinnedGitConfig...), args...)...)
+	cmd.Env = gitEnv(config.UseUserGitConfig)
+	return cmd
+}
+
+// repositoryEnv are the variables that point git at another repository, like
+// those set in hooks, listed by `git rev-parse --local-env-vars`.
+var repositoryEnv = []string{
+	"GIT_DIR", "GIT_WORK_TREE", "GIT_IMPLICIT_WORK_TREE", "GIT_COMMON_DIR", "GIT_INDEX_FILE",
+	"GIT_OBJECT_DIRECTORY", "GIT_ALTERNATE_OBJECT_DIRECTORIES", "GIT_GRAFT_FILE", "GIT_SHALLOW_FILE",
+	"GIT_NO_REPLACE_OBJECTS", "GIT_REPLACE_REF_BASE", "GIT_PREFIX",
+}
+
+// cloneEnv keeps the variables and configs that authenticate the clone, like
+// GIT_SSH_COMMAND, GIT_ASKPASS, credential helpers and insteadOf rewrites.
+func cloneEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if slices.Contains(repositoryEnv, name) {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+func gitEnv(useUserGitConfig bool) []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "GIT_") {
+			continue
+		}
+		env = append(env, kv)
+	}
+
+	if !useUserGitConfig {
+		env = append(env, "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL="+os.DevNull)
+	}
+	return env
+}
@@ -0,0 +1,182 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// gitlabRemoteRegexp matches the project path of https, ssh and scp-like
+// remote URLs of any host.
+var gitlabRemoteRegexp = regexp.MustCompile(`^(?:[a-z+]+://(?:[^@/]+@)?[^/]+/|[^@/]+@[^:/]+:)(.+?)(?:\.git)?/?$`)
+
+// gitlabClient calls the REST API of a GitLab instance.
+type gitlabClient struct {
+	api    string
+	token  string
+	client *http.Client
+}
+
+func newGitlabClient(base, token string) gitlabClient {
+	if base == "" {
+		base = os.Getenv("CI_SERVER_URL")
+	}
+	if base == "" {
+		base = "https://gitlab.com"
+	}
+	return gitlabClient{
+		api:    strings.TrimSuffix(base, "/") + "/api/v4",
+		token:  token,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func newGitlabCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gitlab",
+		Short: "Publishes reports to GitLab",
+	}
+	cmd.AddCommand(newGitlabCommentCmd(config))
+	return cmd
+}
+
+func newGitlabCommentCmd(config *Config) *cobra.Command {
+	var mr int
+	var project string
+
+	cmd := &cobra.Command{
+		Use:   "comment",
+		Short: "Posts the report as a comment on a merge request",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if mr <= 0 {
+				fmt.Fprintf(os.Stderr, "Invalid merge request: %d\n", mr)
+				os.Exit(2)
+			}
+			token := config.GitlabToken
+			if token == "" {
+				token = os.Getenv("GITLAB_TOKEN")
+			}
+			if token == "" {
+				fmt.Fprintf(os.Stderr, "Invalid token: set --gitlab-token or GITLAB_TOKEN\n")
+				os.Exit(2)
+			}
+			if project == "" {
+				project = os.Getenv("CI_PROJECT_ID")
+			}
+			if project == "" {
+				path, err := gitlabProject(*config)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Invalid project: %v\n", err)
+					os.Exit(2)
+				}
+				project = path
+			}
+
+			report := collectStats(*config)
+			if config.Anonymize {
+				report = anonymizeReport(report, *config)
+			}
+			if config.GitlabToken != "" {
+				resolveIdentities(report.Actors, *config)
+			}
+			markdown := *config
+			markdown.Format = "gh-summary"
+			body := renderReport(report, markdown)
+
+			g := newGitlabClient(config.GitlabURL, token)
+			path := fmt.Sprintf("/projects/%s/merge_requests/%d/notes", url.PathEscape(project), mr)
+			if err := g.post(path, map[string]string{"body": body.String()}); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Posting error: %s\n", err)
+				os.Exit(1)
+			}
+			checkPolicies(report, *config)
+		},
+	}
+
+	cmd.Flags().IntVar(&mr, "mr", 0, "Merge request IID")
+	cmd.Flags().StringVar(&project, "project", "", "Project ID or path (default: $CI_PROJECT_ID or the path of origin)")
+
+	return cmd
+}
+
+// gitlabProject returns the project path origin points to.
+func gitlabProject(config Config) (string, error) {
+	out, err := gitCommand(config, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return "", fmt.Errorf("git remote get-url origin: %w", err)
+	}
+
+	m := gitlabRemoteRegexp.FindStringSubmatch(strings.TrimSpace(string(out)))
+	if m == nil {
+		return "", fmt.Errorf("origin is not a remote repository: %s", strings.TrimSpace(string(out)))
+	}
+	return m[1], nil
+}
+
+// lookupGitlabIdentity searches users by the primary email. Only public
+// emails are found unless the token belongs to an administrator.
+func lookupGitlabIdentity(g gitlabClient, a ActorStats) (forgeIdentity, error) {
+	email := primaryEmail(a)
+	if email == "" {
+		return forgeIdentity{}, nil
+	}
+
+	var users []struct {
+		Username  string `json:"username"`
+		AvatarURL string `json:"avatar_url"`
+		WebURL    string `json:"web_url"`
+	}
+	if err := g.do(http.MethodGet, "/users?search="+url.QueryEscape(email), nil, &users); err != nil {
+		return forgeIdentity{}, err
+	}
+	if len(users) != 1 {
+		return forgeIdentity{}, nil
+	}
+	return forgeIdentity{Login: users[0].Username, AvatarURL: users[0].AvatarURL, HTMLURL: users[0].WebURL}, nil
+}
+
+func (g *gitlabClient) post(path string, v any) error {
+	return g.do(http.MethodPost, path, v, nil)
+}
+
+func (g *gitlabClient) do(method, path string, in, out any) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, g.api+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", g.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,157 @@
+//go:build !solution
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// heatmapAll names the row group of all the commits together.
+const heatmapAll = "(all)"
+
+var weekdays = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// heatmap counts commits by weekday, Monday first, and hour.
+type heatmap [7][24]int
+
+func newHeatmapCmd(config *Config) *cobra.Command {
+	var utc bool
+	var authors []string
+
+	cmd := &cobra.Command{
+		Use:   "heatmap",
+		Short: "Counts commits by weekday and hour of the author date, per author and overall",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			maps := commitHeatmaps(*config, utc)
+
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				return writeTable(out, heatmapTable(maps, authors, config), nil, config)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&utc, "utc", false, "Bucket by UTC instead of the time zone of every commit, to see coverage across time zones")
+	cmd.Flags().StringArrayVar(&authors, "author", nil, "Only show this author besides the overall rows (repeatable)")
+
+	return cmd
+}
+
+// commitHeatmaps walks the non-merge commits of the revision. Dates are
+// taken in the time zone recorded in the commit unless utc is set.
+func commitHeatmaps(config Config, utc bool) map[string]*heatmap {
+	format, date := "%ad%x00%an", "--date=format:%u %H"
+	if config.UseCommitter {
+		format = "%cd%x00%cn"
+	}
+	if utc {
+		date = "--date=format-local:%u %H"
+	}
+
+	cmd := gitCommand(config, "log", "--no-merges", "--format="+format, date, config.Revision)
+	if utc {
+		cmd.Env = append(cmd.Env, "TZ=UTC")
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git log: %v\n", err)
+		return nil
+	}
+
+	maps := map[string]*heatmap{heatmapAll: {}}
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		when, actor, ok := strings.Cut(scanner.Text(), "\x00")
+		day, hour, ok2 := strings.Cut(when, " ")
+		d, err := strconv.Atoi(day)
+		h, err2 := strconv.Atoi(hour)
+		if !ok || !ok2 || err != nil || err2 != nil || d < 1 || d > 7 || h < 0 || h > 23 {
+			continue
+		}
+		if maps[actor] == nil {
+			maps[actor] = &heatmap{}
+		}
+		maps[actor][d-1][h]++
+		maps[heatmapAll][d-1][h]++
+	}
+	return maps
+}
+
+func (m *heatmap) total() int {
+	total := 0
+	for _, hours := range m {
+		for _, n := range hours {
+			total += n
+		}
+	}
+	return total
+}
+
+// heatmapTable has seven rows per author, the overall ones first and the
+// authors by their number of commits.
+func heatmapTable(maps map[string]*heatmap, authors []string, config Config) table {
+	names := make([]string, 0, len(maps))
+	if len(authors) > 0 {
+		for _, name := range authors {
+			if maps[name] != nil {
+				names = append(names, name)
+			}
+		}
+	} else {
+		for name := range maps {
+			if name != heatmapAll {
+				names = append(names, name)
+			}
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if a, b := maps[names[i]].total(), maps[names[j]].total(); a != b {
+				return a > b
+			}
+			return names[i] < names[j]
+		})
+	}
+	names = append([]string{heatmapAll}, names...)
+
+	t := table{Headers: []string{"Name", "Day"}}
+	for h := 0; h < 24; h++ {
+		t.Headers = append(t.Headers, fmt.Sprintf("H%02d", h))
+	}
+	t.Headers = append(t.Headers, "Total")
+
+	for _, name := range names {
+		m := maps[name]
+		if m == nil {
+			continue
+		}
+		shown := name
+		if name != heatmapAll {
+			shown = anonymizedName(name, config)
+		}
+		for d, hours := range m {
+			row := []string{shown, weekdays[d]}
+			item := record{{Key: "name", Value: shown}, {Key: "day", Value: weekdays[d]}}
+			total := 0
+			for h, n := range hours {
+				row = append(row, strconv.Itoa(n))
+				item = append(item, field{Key: fmt.Sprintf("h%02d", h), Value: n})
+				total += n
+			}
+			row = append(row, strconv.Itoa(total))
+			item = append(item, field{Key: "total", Value: total})
+			t.Rows = append(t.Rows, row)
+			t.Items = append(t.Items, item)
+		}
+	}
+	return t
+}
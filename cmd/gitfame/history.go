@@ -0,0 +1,151 @@
+//go:build !solution
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type snapshot struct {
+	Period   string
+	Revision string
+}
+
+type HistoryRow struct {
+	Period string
+	ActorStats
+}
+
+func newHistoryCmd(config *Config) *cobra.Command {
+	var interval string
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Computes statistics at every point in time of the revision history",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			validIntervals := map[string]bool{"month": true, "quarter": true, "tag": true}
+			if _, ok := validIntervals[interval]; !ok {
+				fmt.Fprintf(os.Stderr, "Invalid interval: %s\n", interval)
+				os.Exit(2)
+			}
+
+			var rows []HistoryRow
+			var metadata *Metadata
+			for _, s := range listSnapshots(*config, interval) {
+				snapshotConfig := *config
+				snapshotConfig.Revision = s.Revision
+
+				report := collectStats(snapshotConfig)
+				if config.Anonymize {
+					report = anonymizeReport(report, *config)
+				}
+				metadata = report.Metadata
+				for _, actor := range sortedActors(report.Actors, snapshotConfig) {
+					rows = append(rows, HistoryRow{Period: s.Period, ActorStats: actor})
+				}
+			}
+
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				return writeTable(out, historyTable(rows, activeColumns(config)), metadata, config)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&interval, "interval", "month", "Snapshot interval: month, quarter, tag")
+
+	return cmd
+}
+
+func historyTable(rows []HistoryRow, columns []column) table {
+	t := table{
+		Headers: append([]string{"Period"}, columnHeaders(columns)...),
+		Rows:    make([][]string, 0, len(rows)),
+		Items:   make([]record, 0, len(rows)),
+	}
+	for _, row := range rows {
+		t.Rows = append(t.Rows, append([]string{row.Period}, columnValues(columns, row.ActorStats)...))
+		t.Items = append(t.Items, append(record{{Key: "period", Value: row.Period}}, columnRecord(columns, row.ActorStats)...))
+	}
+	return t
+}
+
+// listSnapshots returns the revisions to analyze in chronological order: the
+// last first-parent commit of every month/quarter, or every tag reachable
+// from the revision.
+func listSnapshots(config Config, interval string) []snapshot {
+	if interval == "tag" {
+		return listTagSnapshots(config)
+	}
+
+	cmd := gitCommand(config, "log", "--first-parent", "--format=%H %ct", config.Revision)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git log: %v\n", err)
+		return nil
+	}
+
+	var snapshots []snapshot
+	seen := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 {
+			continue
+		}
+		timestamp, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		period := periodOf(time.Unix(timestamp, 0).UTC(), interval)
+		if _, ok := seen[period]; ok {
+			continue
+		}
+		seen[period] = struct{}{}
+		snapshots = append(snapshots, snapshot{Period: period, Revision: parts[0]})
+	}
+
+	for i, j := 0, len(snapshots)-1; i < j; i, j = i+1, j-1 {
+		snapshots[i], snapshots[j] = snapshots[j], snapshots[i]
+	}
+	return snapshots
+}
+
+func listTagSnapshots(config Config) []snapshot {
+	cmd := gitCommand(config, "tag", "--merged", config.Revision, "--sort=creatordate")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git tag: %v\n", err)
+		return nil
+	}
+
+	var snapshots []snapshot
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		tag := scanner.Text()
+		snapshots = append(snapshots, snapshot{Period: tag, Revision: tag})
+	}
+	return snapshots
+}
+
+func periodOf(t time.Time, interval string) string {
+	if interval == "quarter" {
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+	}
+	return t.Format("2006-01")
+}
@@ -0,0 +1,136 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// hotspotRow combines the history of a file with its current ownership.
+type hotspotRow struct {
+	File    string
+	Commits int
+	Churn   int
+	Lines   int
+	Owner   string
+	Share   float64
+	Authors int
+	Score   float64
+}
+
+func newHotspotsCmd(config *Config) *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "hotspots",
+		Short: "Ranks files that are often changed, large and owned by one actor",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if limit < 0 {
+				fmt.Fprintf(os.Stderr, "Invalid limit: %d\n", limit)
+				os.Exit(2)
+			}
+
+			report := collectStats(*config)
+			if config.Anonymize {
+				report = anonymizeReport(report, *config)
+			}
+			rows := hotspots(report, *config)
+			if limit > 0 && len(rows) > limit {
+				rows = rows[:limit]
+			}
+
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				return writeTable(out, hotspotsTable(rows, config), nil, config)
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 20, "Show the files with the highest scores only, 0 for all")
+
+	return cmd
+}
+
+// hotspots scores the analyzed files by the product of their commit count
+// and size, both relative to the largest in the repository, and the share
+// of their top owner; the highest scores come first.
+func hotspots(report Report, config Config) []hotspotRow {
+	commits := make(map[string]map[string]struct{})
+	churn := make(map[string]int)
+	forEachNumstat(config, func(e numstatEntry) {
+		if commits[e.File] == nil {
+			commits[e.File] = make(map[string]struct{})
+		}
+		commits[e.File][e.Commit] = struct{}{}
+		churn[e.File] += e.Added + e.Deleted
+	})
+
+	rows := make([]hotspotRow, 0, len(report.Files))
+	maxCommits, maxLines := 0, 0
+	for _, file := range report.Files {
+		owner, total, share := topOwner(file.Lines)
+		authors := 0
+		for _, n := range file.Lines {
+			if n > 0 {
+				authors++
+			}
+		}
+		row := hotspotRow{
+			File:    file.Path,
+			Commits: len(commits[file.Path]),
+			Churn:   churn[file.Path],
+			Lines:   total,
+			Owner:   owner,
+			Share:   share,
+			Authors: authors,
+		}
+		maxCommits, maxLines = max(maxCommits, row.Commits), max(maxLines, row.Lines)
+		rows = append(rows, row)
+	}
+
+	for i := range rows {
+		if maxCommits > 0 && maxLines > 0 {
+			rows[i].Score = round3(float64(rows[i].Commits) / float64(maxCommits) *
+				float64(rows[i].Lines) / float64(maxLines) * rows[i].Share / 100)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Score != rows[j].Score {
+			return rows[i].Score > rows[j].Score
+		}
+		return rows[i].File < rows[j].File
+	})
+	return rows
+}
+
+func hotspotsTable(rows []hotspotRow, config Config) table {
+	t := table{
+		Headers: []string{"File", "Commits", "Churn", "Lines", "Owner", "Share%", "Authors", "Score"},
+		Rows:    make([][]string, 0, len(rows)),
+		Items:   make([]record, 0, len(rows)),
+	}
+	for _, r := range rows {
+		file := formatPath(r.File, config)
+		t.Rows = append(t.Rows, []string{
+			file, strconv.Itoa(r.Commits), strconv.Itoa(r.Churn), strconv.Itoa(r.Lines),
+			r.Owner, formatValue(r.Share), strconv.Itoa(r.Authors), formatValue(r.Score),
+		})
+		t.Items = append(t.Items, record{
+			{Key: "file", Value: file},
+			{Key: "commits", Value: r.Commits},
+			{Key: "churn", Value: r.Churn},
+			{Key: "lines", Value: r.Lines},
+			{Key: "owner", Value: r.Owner},
+			{Key: "share", Value: r.Share},
+			{Key: "authors", Value: r.Authors},
+			{Key: "score", Value: r.Score},
+		})
+	}
+	return t
+}
@@ -0,0 +1,148 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// identityCommits is the number of commits of an actor the GitHub API is
+// asked about before falling back to searching users by email.
+const identityCommits = 3
+
+// forgeIdentity is the GitHub or GitLab account behind a git identity.
+type forgeIdentity struct {
+	Login     string `json:"login"`
+	AvatarURL string `json:"avatar_url"`
+	HTMLURL   string `json:"html_url"`
+}
+
+func loginColumns(config Config) []column {
+	login := column{Header: "Login", Key: "login", Value: func(a ActorStats) any { return a.account.Login }}
+	switch config.Format {
+	case "gh-summary":
+		// GitLab notes would mention every author prefixed with @.
+		mention := "@"
+		if config.GitlabToken != "" {
+			mention = ""
+		}
+		login.Value = func(a ActorStats) any {
+			if a.account.Login == "" {
+				return ""
+			}
+			avatar := a.account.AvatarURL
+			if strings.Contains(avatar, "?") {
+				avatar += "&s=20"
+			} else {
+				avatar += "?s=20"
+			}
+			return fmt.Sprintf(`<img src="%s" width="20"> [%s%s](%s)`, avatar, mention, a.account.Login, a.account.HTMLURL)
+		}
+	case "json", "json-lines":
+		return []column{login, {Header: "Avatar", Key: "avatar_url", Value: func(a ActorStats) any { return a.account.AvatarURL }}}
+	}
+	return []column{login}
+}
+
+// resolveIdentities looks up the forge accounts of the actors, --jobs at a
+// time. Actors without an account keep an empty login; the first API error
+// stops the lookups.
+func resolveIdentities(actors map[string]ActorStats, config Config) {
+	var lookup func(ActorStats) (forgeIdentity, error)
+	if config.GitlabToken != "" {
+		g := newGitlabClient(config.GitlabURL, config.GitlabToken)
+		lookup = func(a ActorStats) (forgeIdentity, error) { return lookupGitlabIdentity(g, a) }
+	} else {
+		g := newGithubClient(config.GithubToken)
+		slug, err := githubSlug(config)
+		if err != nil {
+			slug = ""
+		}
+		lookup = func(a ActorStats) (forgeIdentity, error) { return lookupGithubIdentity(g, slug, a, config) }
+	}
+
+	names := make([]string, 0, len(actors))
+	for name := range actors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var failed bool
+	jobs := make(chan string)
+	for w := 0; w < config.Jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for name := range jobs {
+				mu.Lock()
+				a := actors[name]
+				skip := failed
+				mu.Unlock()
+				if skip {
+					continue
+				}
+
+				identity, err := lookup(a)
+				mu.Lock()
+				if err != nil && !failed {
+					fmt.Fprintf(os.Stderr, "Account lookup disabled: %v\n", err)
+					failed = true
+				}
+				a.account = identity
+				actors[name] = a
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, name := range names {
+		jobs <- name
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// lookupGithubIdentity links commits of the analyzed repository to accounts,
+// which works for private emails too, and searches users by the primary
+// email otherwise.
+func lookupGithubIdentity(g githubClient, slug string, a ActorStats, config Config) (forgeIdentity, error) {
+	role := "author"
+	if config.UseCommitter {
+		role = "committer"
+	}
+
+	if slug != "" {
+		commits := sortedKeys(a.commitsSet)
+		for _, commit := range commits[:min(len(commits), identityCommits)] {
+			var c map[string]*forgeIdentity
+			if err := g.get(fmt.Sprintf("/repos/%s/commits/%s", slug, strings.TrimPrefix(commit, "^")), &c); err != nil {
+				return forgeIdentity{}, err
+			}
+			if c[role] != nil && c[role].Login != "" {
+				return *c[role], nil
+			}
+		}
+	}
+
+	email := primaryEmail(a)
+	if email == "" {
+		return forgeIdentity{}, nil
+	}
+	var found struct {
+		Items []forgeIdentity `json:"items"`
+	}
+	if err := g.get("/search/users?q="+url.QueryEscape(email+" in:email"), &found); err != nil {
+		return forgeIdentity{}, err
+	}
+	if len(found.Items) != 1 {
+		return forgeIdentity{}, nil
+	}
+	return found.Items[0], nil
+}
@@ -0,0 +1,39 @@
+//go:build !solution
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// importedActor is credited with every line from commits reachable from
+// --import-boundary.
+const importedActor = "(imported)"
+
+func importedCommits(config Config) (map[string]struct{}, error) {
+	cmd := gitCommand(config, "rev-list", config.ImportBoundary)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git rev-list: %w", err)
+	}
+
+	commits := make(map[string]struct{})
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		commits[scanner.Text()] = struct{}{}
+	}
+	return commits, scanner.Err()
+}
+
+func isImported(commit string, config Config) bool {
+	if config.imported == nil {
+		return false
+	}
+	_, ok := config.imported[strings.TrimPrefix(commit, "^")]
+	return ok
+}
@@ -0,0 +1,107 @@
+//go:build !solution
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// incrementalState holds the results of the previous --incremental run by
+// path, with the blob they were computed for, and collects the results of
+// the current run to replace them. A nil state records nothing.
+type incrementalState struct {
+	path    string
+	options string
+
+	mu       sync.Mutex
+	previous map[string]incrementalFile
+	current  map[string]incrementalFile
+}
+
+type incrementalFile struct {
+	Blob   string            `json:"blob"`
+	EOL    string            `json:"eol,omitempty"`
+	Actors []checkpointActor `json:"actors"`
+}
+
+type incrementalData struct {
+	Options  string                     `json:"options"`
+	Revision string                     `json:"revision"`
+	Files    map[string]incrementalFile `json:"files"`
+}
+
+// openIncremental loads the state file. A missing file or one written with
+// other options starts from scratch.
+func openIncremental(config Config) (*incrementalState, error) {
+	s := &incrementalState{
+		path:     config.Incremental,
+		options:  checkpointOptions(config),
+		previous: make(map[string]incrementalFile),
+		current:  make(map[string]incrementalFile),
+	}
+
+	data, err := os.ReadFile(config.Incremental)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored incrementalData
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("%s: %w", config.Incremental, err)
+	}
+	if stored.Options != s.options {
+		fmt.Fprintf(os.Stderr, "%s was written with %s, blaming all files\n", config.Incremental, stored.Options)
+		return s, nil
+	}
+	if stored.Files != nil {
+		s.previous = stored.Files
+	}
+	return s, nil
+}
+
+// stats returns the stored results of the file if its blob did not change
+// and keeps them for the next run.
+func (s *incrementalState) stats(file, blob string) (map[string]ActorStats, string, bool) {
+	if s == nil || blob == "" {
+		return nil, "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.previous[file]
+	if !ok || entry.Blob != blob {
+		return nil, "", false
+	}
+	s.current[file] = entry
+	return recordedStats(entry.Actors), entry.EOL, true
+}
+
+func (s *incrementalState) record(file, blob string, stats map[string]ActorStats, eol string) {
+	if s == nil || blob == "" || stats == nil {
+		return
+	}
+	entry := incrementalFile{Blob: blob, EOL: eol, Actors: recordActors(stats)}
+	s.mu.Lock()
+	s.current[file] = entry
+	s.mu.Unlock()
+}
+
+// save replaces the state file with the results of this run. Files that
+// were deleted or not analyzed are dropped.
+func (s *incrementalState) save(revision string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	data, err := json.Marshal(incrementalData{Options: s.options, Revision: revision, Files: s.current})
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, append(data, '\n'))
+}
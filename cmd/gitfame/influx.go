@@ -0,0 +1,106 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// influxTagKeys renames record keys to the tags of the influx format.
+var influxTagKeys = map[string]string{
+	"name":       "author",
+	"repository": "repo",
+}
+
+var (
+	influxEscaper            = strings.NewReplacer(",", "\\,", "=", "\\=", " ", "\\ ")
+	influxMeasurementEscaper = strings.NewReplacer(",", "\\,", " ", "\\ ")
+)
+
+// writeInflux renders every item as a line protocol point timestamped with
+// the revision commit time: string values become tags, numbers fields. The
+// main table is the gitfame measurement, sections are gitfame_<name>.
+func writeInflux(out io.Writer, main table, sections []section, config Config) error {
+	var timestamp int64
+	for _, r := range config.repositories {
+		sub := config
+		sub.Repository = r.Path
+		timestamp = max(timestamp, commitTime(sub, config.Revision))
+	}
+	repo := ""
+	if len(config.repositories) == 1 {
+		repo = config.repositories[0].Name
+	}
+
+	points := []struct {
+		measurement string
+		items       []record
+	}{{"gitfame", main.Items}}
+	for _, s := range sections {
+		points = append(points, struct {
+			measurement string
+			items       []record
+		}{"gitfame_" + s.Name, s.Table.Items})
+	}
+
+	for _, p := range points {
+		for _, item := range p.items {
+			if line := influxLine(p.measurement, item, repo, timestamp); line != "" {
+				if _, err := fmt.Fprintln(out, line); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func influxLine(measurement string, item record, repo string, timestamp int64) string {
+	tags := make(map[string]string)
+	if repo != "" {
+		tags["repo"] = repo
+	}
+	var fields []string
+	for _, f := range item {
+		key := f.Key
+		if tag, ok := influxTagKeys[key]; ok {
+			key = tag
+		}
+		switch v := f.Value.(type) {
+		case string:
+			if v != "" && key != "avatar_url" {
+				tags[key] = v
+			}
+		case int:
+			fields = append(fields, influxEscaper.Replace(key)+"="+strconv.Itoa(v)+"i")
+		case float64:
+			fields = append(fields, influxEscaper.Replace(key)+"="+strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	}
+	// A point needs at least one field.
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// Sorted tags are what InfluxDB expects for the best write performance.
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(influxMeasurementEscaper.Replace(measurement))
+	for _, key := range keys {
+		b.WriteString("," + influxEscaper.Replace(key) + "=" + influxEscaper.Replace(tags[key]))
+	}
+	b.WriteString(" " + strings.Join(fields, ","))
+	if timestamp > 0 {
+		b.WriteString(" " + strconv.FormatInt(timestamp, 10) + "000000000")
+	}
+	return b.String()
+}
@@ -0,0 +1,33 @@
+//go:build !solution
+
+package main
+
+import "runtime"
+
+// blameMemory is a rough upper bound of memory used by a single git blame
+// subprocess together with its buffered output.
+const blameMemory = 128 << 20
+
+// defaultJobs sizes the worker pool by the CPU and memory available to the
+// process, honoring container limits where they can be detected.
+func defaultJobs() int {
+	cpus := runtime.NumCPU()
+	if limit, ok := cgroupCPULimit(); ok && limit < cpus {
+		cpus = limit
+		if runtime.GOMAXPROCS(0) > limit {
+			runtime.GOMAXPROCS(limit)
+		}
+	}
+
+	jobs := cpus
+	if limit, ok := cgroupMemoryLimit(); ok {
+		if byMemory := int(limit / blameMemory); byMemory < jobs {
+			jobs = byMemory
+		}
+	}
+
+	if jobs < 1 {
+		jobs = 1
+	}
+	return jobs
+}
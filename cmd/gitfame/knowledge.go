@@ -0,0 +1,29 @@
+//go:build !solution
+
+package main
+
+import "math"
+
+var knowledgeColumn = column{Header: "Knowledge", Key: "knowledge", Numeric: true, Value: func(a ActorStats) any { return a.knowledge }}
+
+// computeKnowledge weighs every surviving line of an actor by its age at the
+// revision date, halving the weight every --knowledge-half-life, so that old
+// code counts less towards what the actor still remembers.
+func computeKnowledge(stats map[string]ActorStats, config Config) {
+	if !config.Knowledge {
+		return
+	}
+
+	revisionTime := commitTime(config, config.Revision)
+	halfLife := config.knowledgeHalfLife.Duration.Seconds()
+	for actor, s := range stats {
+		var sum float64
+		for t, n := range s.authorTimes {
+			// Lines dated after the revision weigh 1.
+			age := math.Max(float64(revisionTime-t), 0)
+			sum += float64(n) * math.Exp2(-age/halfLife)
+		}
+		s.knowledge = math.Round(sum*10) / 10
+		stats[actor] = s
+	}
+}
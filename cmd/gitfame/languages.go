@@ -0,0 +1,53 @@
+//go:build !solution
+
+package main
+
+import (
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+func newLanguagesCmd(config *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "languages",
+		Short: "Lists the languages accepted by --languages with their extensions and file names",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				return writeTable(out, languagesTable(config), nil, config)
+			})
+		},
+	}
+}
+
+func languagesTable(config Config) table {
+	names := make([]string, 0, len(config.ExtensionsMap))
+	for lang := range config.ExtensionsMap {
+		names = append(names, lang)
+	}
+	for lang := range config.FilenamesMap {
+		if _, ok := config.ExtensionsMap[lang]; !ok {
+			names = append(names, lang)
+		}
+	}
+	sort.Strings(names)
+
+	t := table{
+		Headers: []string{"Language", "Filenames", "Extensions"},
+		Rows:    make([][]string, 0, len(names)),
+		Items:   make([]record, 0, len(names)),
+	}
+	for _, lang := range names {
+		extensions := append([]string{}, config.ExtensionsMap[lang]...)
+		filenames := append([]string{}, config.FilenamesMap[lang]...)
+		t.Rows = append(t.Rows, []string{lang, formatValue(filenames), formatValue(extensions)})
+		t.Items = append(t.Items, record{
+			{Key: "language", Value: lang},
+			{Key: "filenames", Value: filenames},
+			{Key: "extensions", Value: extensions},
+		})
+	}
+	return t
+}
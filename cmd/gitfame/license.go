@@ -0,0 +1,43 @@
+//go:build !solution
+
+package main
+
+import "strings"
+
+// licenseKeywords mark a comment block as a license or copyright header.
+var licenseKeywords = []string{"copyright", "license", "licence", "spdx-license-identifier", "all rights reserved"}
+
+// markLicenseHeader turns the comment blocks before the first line of code
+// that mention a license or a copyright into license lines. Blocks are
+// separated by blank lines, so a doc comment after the header is kept; a
+// shebang line is kept too.
+func markLicenseHeader(kinds lineKinds, lines []string) {
+	start := 0
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "#!") {
+		start = 1
+	}
+	for i := start; i <= len(kinds); i++ {
+		if i < len(kinds) && kinds[i] == commentLine {
+			continue
+		}
+		if isLicenseBlock(lines[start:i]) {
+			for j := start; j < i; j++ {
+				kinds[j] = licenseLine
+			}
+		}
+		if i == len(kinds) || kinds[i] == codeLine {
+			return
+		}
+		start = i + 1
+	}
+}
+
+func isLicenseBlock(lines []string) bool {
+	text := strings.ToLower(strings.Join(lines, "\n"))
+	for _, keyword := range licenseKeywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+//go:build !solution
+
+package main
+
+import (
+	"strings"
+
+	"gogitfame/configs"
+)
+
+type lineKind int
+
+const (
+	codeLine lineKind = iota
+	commentLine
+	blankLine
+	// licenseLine is a comment line of a license header, not credited with
+	// --exclude-license-headers.
+	licenseLine
+)
+
+// lineKinds holds the kind of every line of a file, first line first.
+type lineKinds []lineKind
+
+// at returns the kind of the 1-based line n; lines past the content are code.
+func (k lineKinds) at(n int) lineKind {
+	if n < 1 || n > len(k) {
+		return codeLine
+	}
+	return k[n-1]
+}
+
+// fileKinds classifies the lines of a file with the comment syntax of its
+// language. Files of languages without known comments only have code and
+// blank lines.
+func fileKinds(file string, lines []string, config Config) lineKinds {
+	syntax := config.CommentSyntax[languageOf(file, config)]
+	var inBlock string
+
+	kinds := make(lineKinds, len(lines))
+	for i, line := range lines {
+		kinds[i], inBlock = classifyLine(line, syntax, inBlock)
+	}
+	if config.ExcludeLicenses {
+		markLicenseHeader(kinds, lines)
+	}
+	return kinds
+}
+
+// classifyLine returns the kind of the line and the end token of the block
+// comment still open after it. Comments are only recognized at the start of
+// the line or right after a block comment ends on it: a line that starts
+// with code is code, so comment tokens inside its string literals cannot
+// open a block.
+func classifyLine(line string, syntax configs.CommentSyntax, inBlock string) (lineKind, string) {
+	rest := strings.TrimSpace(line)
+	if rest == "" {
+		return blankLine, inBlock
+	}
+
+	for rest != "" {
+		if inBlock != "" {
+			i := strings.Index(rest, inBlock)
+			if i < 0 {
+				return commentLine, inBlock
+			}
+			rest = strings.TrimSpace(rest[i+len(inBlock):])
+			inBlock = ""
+			continue
+		}
+
+		// The longest token wins, so that block openers like Lua --[[ or
+		// Julia #= are not taken for the line comment they start with.
+		var start, end string
+		for _, token := range syntax.Line {
+			if strings.HasPrefix(rest, token) && len(token) > len(start) {
+				start, end = token, ""
+			}
+		}
+		for _, block := range syntax.Block {
+			if strings.HasPrefix(rest, block[0]) && len(block[0]) >= len(start) {
+				start, end = block[0], block[1]
+			}
+		}
+		switch {
+		case start == "":
+			return codeLine, ""
+		case end == "":
+			return commentLine, ""
+		}
+		rest, inBlock = rest[len(start):], end
+	}
+	return commentLine, inBlock
+}
+
+// classifyGroup counts the kinds of the lines of the group and, with
+// --count=code, credits only its code lines. License header lines are
+// never credited. The credited lines are also summed by their weights.
+func classifyGroup(g *blameGroup, kinds lineKinds, weights []int, config Config) {
+	for i := 0; i < g.Count; i++ {
+		g.addLine(kinds.at(g.FinalLine+i), weightAt(weights, g.FinalLine+i), config)
+	}
+	g.applyCount(config)
+}
+
+// addLine counts one line of the group.
+func (g *blameGroup) addLine(kind lineKind, weight int, config Config) {
+	g.addKind(kind)
+	if creditedLine(kind, config) {
+		g.Weighted += weight
+	}
+}
+
+func (g *blameGroup) addKind(kind lineKind) {
+	switch kind {
+	case codeLine:
+		g.Code++
+	case commentLine:
+		g.Comments++
+	case blankLine:
+		g.Blank++
+	case licenseLine:
+		g.License++
+	}
+}
+
+func (g *blameGroup) applyCount(config Config) {
+	if config.Count == "code" {
+		g.Lines = g.Code
+	} else {
+		g.Lines -= g.License
+	}
+}
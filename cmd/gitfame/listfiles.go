@@ -0,0 +1,214 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/spf13/cobra"
+)
+
+var validFileDetails = map[string]string{
+	"size":       "Size",
+	"language":   "Language",
+	"matched-by": "MatchedBy",
+}
+
+func newListFilesCmd(config *Config) *cobra.Command {
+	var details []string
+
+	cmd := &cobra.Command{
+		Use:   "list-files",
+		Short: "Prints the files left after filtering",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, d := range details {
+				if _, ok := validFileDetails[d]; !ok {
+					fmt.Fprintf(os.Stderr, "Invalid detail: %s\n", d)
+					os.Exit(2)
+				}
+			}
+
+			var files []string
+			for file := range parallelFilter(analyzedFiles(*config), *config) {
+				files = append(files, file)
+			}
+			sort.Strings(files)
+
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				if len(details) == 0 && config.Format == "tabular" {
+					// A bare list is easy to feed into other tools.
+					for _, file := range files {
+						fmt.Fprintln(out, formatPath(file, config))
+					}
+					return nil
+				}
+				return writeTable(out, filesTable(files, details, config), nil, config)
+			})
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&details, "details", []string{}, "Extra per-file columns: size, language, matched-by")
+
+	return cmd
+}
+
+func filesTable(files []string, details []string, config Config) table {
+	var sizes map[string]int64
+	for _, d := range details {
+		if d == "size" {
+			sizes = fileSizes(config)
+		}
+	}
+
+	t := table{
+		Headers: []string{"Path"},
+		Rows:    make([][]string, 0, len(files)),
+		Items:   make([]record, 0, len(files)),
+	}
+	for _, d := range details {
+		t.Headers = append(t.Headers, validFileDetails[d])
+	}
+
+	for _, file := range files {
+		path := formatPath(file, config)
+		row := []string{path}
+		item := record{{Key: "path", Value: path}}
+
+		for _, d := range details {
+			var value any
+			switch d {
+			case "size":
+				value = sizes[file]
+			case "language":
+				value = languageOf(file, config)
+			case "matched-by":
+				value = strings.Join(matchReasons(file, config), "; ")
+			}
+			row = append(row, formatValue(value))
+			item = append(item, field{Key: strings.ReplaceAll(d, "-", "_"), Value: value})
+		}
+
+		t.Rows = append(t.Rows, row)
+		t.Items = append(t.Items, item)
+	}
+	return t
+}
+
+func fileSizes(config Config) map[string]int64 {
+	cmd := gitCommand(config, append([]string{"ls-tree", "-r", "-l", "-z", config.Revision, "--"}, config.Paths...)...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git ls-tree: %v\n", err)
+		return nil
+	}
+
+	sizes := make(map[string]int64)
+	for _, line := range strings.Split(stdout.String(), "\x00") {
+		meta, path, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 4 {
+			continue
+		}
+		if size, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+			sizes[path] = size
+		}
+	}
+	return sizes
+}
+
+// extensionLanguage returns the language of a file by its name or extension,
+// preferring languages selected with --languages when it is ambiguous.
+func extensionLanguage(file string, config Config) string {
+	var candidates []string
+	for lang := range config.FilenamesMap {
+		if matchesFilename(file, lang, config) {
+			candidates = append(candidates, lang)
+		}
+	}
+
+	ext := filepath.Ext(file)
+	if len(candidates) > 0 || ext == "" {
+		return preferredLanguage(candidates, config)
+	}
+
+	for lang, extensions := range config.ExtensionsMap {
+		for _, e := range extensions {
+			if e == ext {
+				candidates = append(candidates, lang)
+				break
+			}
+		}
+	}
+	return preferredLanguage(candidates, config)
+}
+
+func preferredLanguage(candidates []string, config Config) string {
+	sort.Strings(candidates)
+
+	for _, selected := range config.Languages {
+		for _, lang := range candidates {
+			if lang == strings.ToLower(selected) {
+				return lang
+			}
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0]
+	}
+	return ""
+}
+
+// matchReasons explains which inclusion filters let the file through.
+func matchReasons(file string, config Config) []string {
+	var reasons []string
+
+	for _, ext := range config.Extensions {
+		if hasExtension(file, ext) {
+			reasons = append(reasons, "extension "+ext)
+			break
+		}
+	}
+	if len(config.Languages) > 0 {
+		if lang := languageOf(file, config); lang != "" {
+			reasons = append(reasons, "language "+lang)
+		}
+	}
+	for _, pattern := range config.RestrictTo {
+		if matched, _ := doublestar.Match(pattern, file); matched {
+			reasons = append(reasons, "restrict-to "+pattern)
+			break
+		}
+	}
+
+	if len(reasons) == 0 {
+		reasons = append(reasons, "default")
+	}
+	return reasons
+}
+
+// matchesFilename reports whether the base name of the file matches one of
+// the file name patterns of the language, like Dockerfile or Makefile.*.
+func matchesFilename(file, language string, config Config) bool {
+	base := path.Base(file)
+	for _, pattern := range config.FilenamesMap[language] {
+		if matched, _ := path.Match(pattern, base); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -3,21 +3,18 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
-	"text/tabwriter"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
@@ -25,16 +22,131 @@ import (
 )
 
 type Config struct {
-	Repository    string
-	Revision      string
-	OrderBy       string
-	UseCommitter  bool
-	Format        string
-	Extensions    []string
-	Languages     []string
-	Exclude       []string
-	RestrictTo    []string
+	Repository string
+	// Repositories are the paths or URLs given with --repository and
+	// --repos-file; stats of several repositories are merged.
+	Repositories []string
+	ReposFile    string
+	repositories []repository
+	// CloneDepth makes the clone of a remote Repository shallow.
+	CloneDepth        int
+	RecurseSubmodules bool
+	Revision          string
+	OrderBy           string
+	Columns           []string
+	AlignNumbers      bool
+	Color             string
+	Metric            string
+	DimBelow          string
+	MaxColumnWidth    int
+	Sort              string
+	TieBreak          []string
+	UseCommitter      bool
+	// UseUserGitConfig lets git read the global and system configs.
+	UseUserGitConfig bool
+	Format           string
+	Outputs          []string
+	Compress         string
+	Extensions       []string
+	Languages        []string
+	Exclude          []string
+	ExcludeFrom      string
+	FilesFrom        string
+	filesFrom        map[string]bool
+	Presets          []string
+	MaxFileSize      string
+	maxFileSize      int64
+	RestrictTo       []string
+	Mode             string
+	BlameStrategy    string
+	Count            string
+	Estimate         bool
+	SampleFiles      string
+	ValidateOutput   bool
+	PathStyle        string
+	Velocity         []string
+	Survival         bool
+	ShowRoles        bool
+	Age              bool
+	Activity         bool
+	DepthProfile     bool
+	// NoOwnerThreshold is a percentage like "25%".
+	NoOwnerThreshold     string
+	Concentration        bool
+	FailIfTopOwnerAbove  string
+	FailIfBusFactorBelow int
+	Breakdown            string
+	EOLReport            bool
+	Detect               string
+	ExcludeGenerated     bool
+	// NoDefaultExcludes keeps files in vendor/, node_modules/ and the like.
+	NoDefaultExcludes bool
+	ImportBoundary    string
+	imported          map[string]struct{}
+	ForgeAttribution  string
+	GithubToken       string
+	GitlabToken       string
+	GitlabURL         string
+	NotifySlack       string
+	SlackBaseline     string
+	PublishURL        string
+	PublishHeaders    []string
+	OtelEndpoint      string
+	Stats             string
+	tracer            *tracer
+	forge             *githubAttribution
+	content           *contentCache
+	// IgnoreGitattributes disables linguist attributes from .gitattributes.
+	IgnoreGitattributes bool
+	attributes          *attributeCache
+	Jobs                int
+	windows             []window
+	Sparkline           int
+	SparklinePeriod     string
+	sparklinePeriod     window
+	Knowledge           bool
+	KnowledgeHalfLife   string
+	knowledgeHalfLife   window
+	ConfigFile          string
+	NoConfig            bool
+	Profile             string
+	AccessReview        string
+	PartialOutput       string
+	// Checkpoint is the file with per-file results of an interrupted run.
+	Checkpoint string
+	Resume     bool
+	checkpoint *checkpoint
+	// Paths limit the analysis to these files and directories.
+	Paths []string
+	// All and Branches blame the union of the branches instead of Revision.
+	All      bool
+	Branches string
+	// revisionName is --revision as given, for messages.
+	revisionName string
+	// Incremental is the file with the per-file results of the last run.
+	Incremental   string
+	incremental   *incrementalState
+	LanguagesFile string
+	File          configs.File
 	ExtensionsMap map[string][]string
+	FilenamesMap  map[string][]string
+	CommentSyntax map[string]configs.CommentSyntax
+	// ExcludeLicenses leaves license comments at the top of files out.
+	ExcludeLicenses bool
+	// Weighted adds the WeightedLines column.
+	Weighted bool
+	// ShowFiles lists the files of every actor under it.
+	ShowFiles bool
+	TopAreas  int
+	// ByFile replaces the actors with the owner of every file.
+	ByFile     bool
+	OwnerAbove string
+	ByFileSort string
+	// Anonymize replaces the names and emails of the actors in the report.
+	Anonymize     bool
+	AnonymizeSalt string
+	// DedupeBlobs blames a blob shared by several paths only once.
+	DedupeBlobs bool
 }
 
 type ActorStats struct {
@@ -43,69 +155,840 @@ type ActorStats struct {
 	commitsSet map[string]struct{}
 	Commits    int `json:"commits"`
 	Files      int `json:"files"`
+	// authorTimes maps author-time of blamed lines to the number of lines.
+	authorTimes map[int64]int
+	velocity    []int
+	added       int
+	avgAge      float64
+	medianAge   float64
+	linesLow    int
+	linesHigh   int
+	avgDepth    float64
+	p90Depth    int
+	// roles counts lines by the other identity of the blamed commits:
+	// committers of an author's lines, or authors of a committer's lines.
+	roles map[string]int
+	// emails counts lines by the email the actor used.
+	emails  map[string]int
+	account forgeIdentity
+	// trend counts surviving lines by --sparkline period, oldest first.
+	trend []int
+	// knowledge is the lines weighted by their age, see computeKnowledge.
+	knowledge float64
+	// areas are the --top-areas directories with their share.
+	areas []string
+	// share is the percentage of all lines, set by sortedActors.
+	share float64
+	// code, comments and blank split the blamed lines by kind.
+	code     int
+	comments int
+	blank    int
+	// weighted is the lines weighted by complexity, see lineWeights.
+	weighted int
+}
+
+// FileStats holds the number of lines every actor owns in a file and the
+// commits those lines come from.
+type FileStats struct {
+	Path    string
+	Lines   map[string]int
+	Commits map[string]map[string]struct{}
+	// EOL is the eolStyle of files whose line counts were normalized.
+	EOL string
+}
+
+type Report struct {
+	Actors   map[string]ActorStats
+	Files    []FileStats
+	Metadata *Metadata
+	// revisions are the commits of merged partial results.
+	revisions map[string]string
+}
+
+type Metadata struct {
+	Mode        string `json:"mode"`
+	Approximate bool   `json:"approximate"`
+	// Revision is the analyzed commit of a single repository.
+	Revision string `json:"revision,omitempty"`
+}
+
+func (m *Metadata) String() string {
+	s := fmt.Sprintf("mode: %s, approximate: %t", m.Mode, m.Approximate)
+	if m.Revision != "" {
+		s += ", revision: " + m.Revision
+	}
+	return s
 }
 
 func main() {
 	var config Config
 
 	var rootCmd = &cobra.Command{
-		Use:   "gitfare",
+		Use:   "gitfare [-- path...]",
 		Short: "Collects statistics from a git repository",
+		// Paths go after "--", so that mistyped subcommands are not taken for
+		// paths.
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 && cmd.ArgsLenAtDash() != 0 {
+				return fmt.Errorf("unknown command %q for %q", args[0], cmd.CommandPath())
+			}
+			return nil
+		},
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if cmd.HasParent() {
+				rejectReportFlags(cmd)
+			}
+			prepareRepositories(&config, cmd.Flags())
+		},
 		Run: func(cmd *cobra.Command, args []string) {
-			config.ExtensionsMap = configs.LoadExtensionsMap()
-
-			files := getFiles(config)
-			filteredFiles := parallelFilter(files, config)
-			actorStats := aggregateStats(filteredFiles, config)
-			outputResults(actorStats, config)
+			config.Paths = args
+			outputResults(collectStats(config), config)
 		},
 	}
 
-	rootCmd.Flags().StringVar(&config.Repository, "repository", ".", "Path to the git repository")
-	rootCmd.Flags().StringVar(&config.Revision, "revision", "HEAD", "Commit reference")
-	rootCmd.Flags().StringVar(&config.OrderBy, "order-by", "lines", "Order of results: lines, commits, files")
-	rootCmd.Flags().BoolVar(&config.UseCommitter, "use-committer", false, "Use committer instead of author")
-	rootCmd.Flags().StringVar(&config.Format, "format", "tabular", "Output format: tabular, csv, json, json-lines")
-	rootCmd.Flags().StringSliceVar(&config.Extensions, "extensions", []string{}, "List of file extensions to include")
-	rootCmd.Flags().StringSliceVar(&config.Languages, "languages", []string{}, "List of languages to include")
-	rootCmd.Flags().StringSliceVar(&config.Exclude, "exclude", []string{}, "Glob patterns to exclude files")
-	rootCmd.Flags().StringSliceVar(&config.RestrictTo, "restrict-to", []string{}, "Glob patterns to restrict files to")
+	flags := rootCmd.PersistentFlags()
+	flags.StringArrayVar(&config.Repositories, "repository", []string{"."}, "Path or URL of the git repository; URLs are cloned to a temporary directory. Repeat to merge several repositories")
+	flags.StringVar(&config.ReposFile, "repos-file", "", "File with repository paths or URLs, one per line ('#' starts a comment)")
+	flags.IntVar(&config.CloneDepth, "clone-depth", 0, "Shallow clone a remote repository with this many commits (0 clones the full history)")
+	flags.BoolVar(&config.RecurseSubmodules, "recurse-submodules", false, "Also analyze checked out submodules at their recorded commits")
+	flags.BoolVar(&config.All, "all", false, "Blame the tips of all local and remote-tracking branches, counting shared lines once")
+	flags.StringVar(&config.Branches, "branches", "", "Like --all, for the branches matching this glob")
+	flags.StringVar(&config.Revision, "revision", "HEAD", "Commit reference: a branch, tag or any revision expression git accepts")
+	flags.StringVar(&config.OrderBy, "order-by", "lines", "Order of results: lines, commits, files, name, or several keys compared in turn, like commits,lines")
+	flags.StringSliceVar(&config.Columns, "columns", nil, "Columns to show, in this order, by their json keys: name, lines, commits, files, percent, email and those added by other flags")
+	flags.StringVar(&config.Metric, "metric", "lines", "Value charted by --format=chart: lines, commits, files")
+	flags.StringVar(&config.Color, "color", "auto", "Color tabular output: auto (when stdout is a terminal), always, never")
+	flags.StringVar(&config.DimBelow, "dim-below", "1%", "Dim the rows of actors with less than this share of lines in colored output")
+	flags.BoolVar(&config.AlignNumbers, "align-numbers", false, "Right-align numeric columns of tabular output")
+	flags.IntVar(&config.MaxColumnWidth, "max-column-width", 0, "Cut text cells of tabular output wider than this with an ellipsis (0 keeps them whole)")
+	flags.StringVar(&config.Sort, "sort", "", "Direction of the order-by keys: asc, desc (default: desc for numbers, asc for name)")
+	flags.StringSliceVar(&config.TieBreak, "tie-break", []string{"name"}, "Keys ordering actors with equal lines, commits and files: name, email")
+	flags.BoolVar(&config.UseCommitter, "use-committer", false, "Use committer instead of author")
+	flags.BoolVar(&config.UseUserGitConfig, "use-user-gitconfig", false, "Let git read the global and system configs (ignored by default for reproducible results)")
+	flags.StringVar(&config.Format, "format", "tabular", "Output format: tabular, csv, json, json-lines, gh-summary, influx, chart")
+	flags.StringVar(&config.Compress, "compress", "", "Compress the files of --output, --partial-output and --access-review: gzip, zstd")
+	flags.StringArrayVar(&config.Outputs, "output", nil, "Write the report, or the output of a subcommand, to this file instead of stdout, atomically; the extension (.txt, .csv, .json, .jsonl, .md) picks the format (repeatable)")
+	flags.StringSliceVar(&config.Extensions, "extensions", []string{}, "List of file extensions to include")
+	flags.StringSliceVar(&config.Languages, "languages", []string{}, "List of languages to include")
+	flags.StringSliceVar(&config.Exclude, "exclude", []string{}, "Glob patterns to exclude files")
+	flags.StringVar(&config.MaxFileSize, "max-file-size", "", "Skip files larger than this size, e.g. 1MB")
+	flags.StringSliceVar(&config.Presets, "preset", []string{}, "Exclude presets for common ecosystems: node, go, python, monorepo-ci")
+	flags.StringVar(&config.FilesFrom, "files-from", "", "Analyze exactly the paths in this file, '-' for stdin (NUL- or newline-separated)")
+	flags.StringVar(&config.ExcludeFrom, "exclude-from", "", "File with exclude glob patterns, one per line ('#' starts a comment)")
+	flags.StringSliceVar(&config.RestrictTo, "restrict-to", []string{}, "Glob patterns to restrict files to")
+	flags.StringVar(&config.Mode, "mode", "blame", "Analysis mode: blame, fast (approximate, from git log --numstat)")
+	flags.StringVar(&config.BlameStrategy, "blame-strategy", "porcelain", "How blame output is read: porcelain (--line-porcelain), incremental (--incremental, less output)")
+	flags.StringVar(&config.Count, "count", "all", "Lines to credit: all, code (without comments and blank lines)")
+	flags.BoolVar(&config.Weighted, "weighted", false, "Add a WeightedLines column: lines of Go functions weigh their cyclomatic complexity")
+	flags.BoolVar(&config.ExcludeLicenses, "exclude-license-headers", false, "Do not credit license and copyright comments at the top of files")
+	flags.BoolVar(&config.Estimate, "estimate", false, "Blame a stratified sample of files and extrapolate lines with 95% confidence intervals")
+	flags.StringVar(&config.SampleFiles, "sample-files", "5%", "Share of files in every directory and size class blamed by --estimate")
+	flags.StringVar(&config.PathStyle, "path-style", "relative", "How file paths are rendered: relative, absolute, uri")
+	flags.StringSliceVar(&config.Velocity, "velocity", []string{}, "Rolling windows for surviving lines added, e.g. 30d,90d,365d")
+	flags.IntVar(&config.Sparkline, "sparkline", 0, "Add a Trend column with the surviving lines added in each of the last N periods")
+	flags.StringVar(&config.SparklinePeriod, "sparkline-period", "30d", "Length of a --sparkline period, e.g. 7d, 4w")
+	flags.IntVar(&config.TopAreas, "top-areas", 0, "Add a TopAreas column with the N directories where every actor owns the most lines")
+	flags.BoolVar(&config.ByFile, "by-file", false, "Print one row per file with its top owner and their share of the lines")
+	flags.StringVar(&config.OwnerAbove, "owner-above", "", "With --by-file, only list files whose top owner holds more than this share, like 70%")
+	flags.StringVar(&config.ByFileSort, "by-file-sort", "path", "Order of --by-file rows: path, entropy (files known by the fewest authors first)")
+	flags.BoolVar(&config.Anonymize, "anonymize", false, "Replace author names and emails with stable salted hashes in every output (not supported by codeowners)")
+	flags.StringVar(&config.AnonymizeSalt, "anonymize-salt", os.Getenv("GITFAME_ANONYMIZE_SALT"), "Secret salt of the --anonymize hashes")
+	flags.BoolVar(&config.ShowFiles, "show-files", false, "List the files every actor owns lines in, indented in tabular output and nested in json")
+	flags.BoolVar(&config.Knowledge, "knowledge", false, "Add a Knowledge column: surviving lines weighted down by their age")
+	flags.StringVar(&config.KnowledgeHalfLife, "knowledge-half-life", "1y", "Age at which a line weighs half in the Knowledge column, e.g. 180d")
+	flags.BoolVar(&config.Survival, "survival", false, "Add Survival% column: surviving lines / lines ever added")
+	flags.BoolVar(&config.ShowRoles, "show-roles", false, "Add a column with the top 3 committers who landed each author's code")
+	flags.BoolVar(&config.Age, "age", false, "Add average and median age in days of surviving lines")
+	flags.BoolVar(&config.Activity, "activity", false, "Add FirstCommit and LastCommit date columns from blamed lines")
+	flags.BoolVar(&config.DepthProfile, "depth-profile", false, "Add average and 90th percentile directory depth of each author's lines")
+	flags.StringVar(&config.FailIfTopOwnerAbove, "fail-if-top-owner-above", "", "Exit with code 3 when one actor owns more than this share of lines, like 70%")
+	flags.IntVar(&config.FailIfBusFactorBelow, "fail-if-bus-factor-below", 0, "Exit with code 4 when fewer actors than this own more than half of the lines")
+	flags.BoolVar(&config.Concentration, "concentration", false, "Add a section with the Gini coefficient and Herfindahl index of line ownership, overall and per directory")
+	flags.StringVar(&config.NoOwnerThreshold, "no-owner-threshold", "", "List directories where no author owns at least this share of lines, e.g. 25%")
+	flags.StringVar(&config.Breakdown, "breakdown", "", "Split rows by dimension: language, repository")
+	flags.BoolVar(&config.EOLReport, "eol-report", false, "List files whose CR-only or mixed line endings were normalized")
+	flags.StringVar(&config.Detect, "detect", "extension", "Language detection strategy: extension, content (go-enry)")
+	flags.StringVar(&config.ImportBoundary, "import-boundary", "", "Credit lines from this revision and its ancestors to a synthetic \"(imported)\" author")
+	flags.StringVar(&config.ForgeAttribution, "forge-attribution", "", "Credit squash-merged pull requests to their authors using forge metadata: github (uses GITHUB_TOKEN)")
+	flags.StringVar(&config.GithubToken, "github-token", "", "Add a Login column with the GitHub accounts of the authors, looked up with this token")
+	flags.StringVar(&config.GitlabToken, "gitlab-token", "", "Add a Login column with the GitLab accounts of the authors, looked up with this token")
+	flags.StringVar(&config.GitlabURL, "gitlab-url", "", "GitLab instance (default: $CI_SERVER_URL or https://gitlab.com)")
+	flags.StringVar(&config.NotifySlack, "notify-slack", "", "Post a summary of the top contributors and the bus factor to this Slack webhook")
+	flags.StringVar(&config.SlackBaseline, "slack-baseline", "", "Revision the Slack summary reports changes against")
+	flags.StringVar(&config.PublishURL, "publish-url", "", "POST the report in json to this URL after the run")
+	flags.StringArrayVar(&config.PublishHeaders, "publish-header", nil, "Header of the --publish-url request, like \"Authorization: Bearer $TOKEN\"; environment variables are expanded (repeatable)")
+	flags.StringVar(&config.OtelEndpoint, "otel-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "Export spans of the analysis stages to this OTLP/HTTP collector, like http://localhost:4318")
+	flags.StringVar(&config.Stats, "stats", "", "Print file counts, blame time, stage wall times and peak concurrency to stderr: text, json")
+	flags.Lookup("stats").NoOptDefVal = "text"
+	flags.BoolVar(&config.NoDefaultExcludes, "no-default-excludes", false, "Do not skip vendor/, node_modules/, third_party/ and dist/ directories")
+	flags.BoolVar(&config.ExcludeGenerated, "exclude-generated", false, "Skip files whose header marks them as generated (\"Code generated by\", \"DO NOT EDIT\", ...)")
+	flags.BoolVar(&config.IgnoreGitattributes, "ignore-gitattributes", false, "Ignore linguist-vendored, linguist-generated, linguist-documentation and linguist-language attributes")
+	flags.IntVar(&config.Jobs, "jobs", 0, "Number of concurrent git blame processes (default: detected from CPU and cgroup limits)")
+	flags.StringVar(&config.Checkpoint, "checkpoint", "", "Record per-file results in this file as they complete")
+	flags.BoolVar(&config.Resume, "resume", false, "Reuse the results recorded in --checkpoint by an interrupted run")
+	flags.StringVar(&config.Incremental, "incremental", "", "Blame only files whose blob changed since the run recorded in this file, then update it")
+	flags.BoolVar(&config.DedupeBlobs, "dedupe-blobs", false, "Blame files with identical content once; every copy is attributed like the first path, not to whoever copied it")
+	flags.StringVar(&config.PartialOutput, "partial-output", "", "Also write a result file with commit ids to this file, for the merge subcommand")
+	flags.StringVar(&config.AccessReview, "access-review", "", "Write a CSV with the top owners of every access_review path set from --config to this file")
+	flags.StringVar(&config.ConfigFile, "config", "", "Path to a YAML config file (default: .gitfame.yaml in the repository and ~/.config/gitfame/config.yaml)")
+	flags.BoolVar(&config.NoConfig, "no-config", false, "Do not look for config files")
+	flags.StringVar(&config.Profile, "profile", "", "Named profile from the config file with flag values to use")
+	flags.StringVar(&config.LanguagesFile, "languages-file", "", "JSON file in the language_extensions.json format merged over the embedded mapping")
+	flags.BoolVar(&config.ValidateOutput, "validate-output", false, "Validate generated output against the column registry before writing")
+
+	rootCmd.AddCommand(newHistoryCmd(&config))
+	rootCmd.AddCommand(newDiffCmd(&config))
+	rootCmd.AddCommand(newDeletionsCmd(&config))
+	rootCmd.AddCommand(newListFilesCmd(&config))
+	rootCmd.AddCommand(newCompareAuthorsCmd(&config))
+	rootCmd.AddCommand(newBranchesCmd(&config))
+	rootCmd.AddCommand(newLanguagesCmd(&config))
+	rootCmd.AddCommand(newOrgCmd(&config))
+	rootCmd.AddCommand(newMergeCmd(&config))
+	rootCmd.AddCommand(newVersionCmd(&config))
+	rootCmd.AddCommand(newWhatIfCmd(&config))
+	rootCmd.AddCommand(newCodeownersCmd(&config))
+	rootCmd.AddCommand(newSuggestReviewersCmd(&config))
+	rootCmd.AddCommand(newGitlabCmd(&config))
+	rootCmd.AddCommand(newHeatmapCmd(&config))
+	rootCmd.AddCommand(newFunctionsCmd(&config))
+	rootCmd.AddCommand(newHotspotsCmd(&config))
 
 	cobra.OnInitialize(func() {
-		validateConfig(&config, rootCmd.Flags())
+		validateConfig(&config, flags)
+		config.ExtensionsMap = configs.LoadExtensionsMap()
+		config.FilenamesMap = configs.LoadFilenamesMap()
+		config.CommentSyntax = configs.LoadCommentSyntax()
+		if config.LanguagesFile != "" {
+			if err := configs.MergeLanguagesFile(config.LanguagesFile, config.ExtensionsMap, config.FilenamesMap); err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid languages file: %v\n", err)
+				os.Exit(2)
+			}
+		}
 	})
 
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	config.tracer.flush()
+	removeClones()
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
 
+func collectStats(config Config) Report {
+	if len(config.repositories) > 1 {
+		return collectRepositories(config)
+	}
+
+	if commit, ok := resolveRevision(config, config.Revision); ok {
+		config.Revision = commit
+	}
+
+	if config.All || config.Branches != "" {
+		stats, fileStats := branchesStats(config)
+		span := config.tracer.start("aggregate", nil)
+		defer span.finish()
+		return finishStats(stats, fileStats, config)
+	}
+
+	span := config.tracer.start("ls-tree", nil, "repository", config.Repository)
+	files := analyzedFiles(config)
+	span.set("files", strconv.Itoa(len(files)))
+	span.finish()
+	filteredFiles := parallelFilter(files, config)
+
+	if config.Mode == "fast" {
+		span := config.tracer.start("numstat", nil)
+		defer span.finish()
+		stats, fileStats := fastStats(filteredFiles, config)
+		fileStats = addSubmoduleStats(stats, fileStats, config)
+		computeDepth(stats, fileStats, config)
+		return Report{Actors: stats, Files: fileStats, Metadata: &Metadata{Mode: config.Mode, Approximate: true, Revision: config.Revision}}
+	}
+
+	if config.Estimate {
+		span := config.tracer.start("estimate", nil)
+		defer span.finish()
+		stats, fileStats := estimateStats(filteredFiles, config)
+		computeDepth(stats, fileStats, config)
+		return Report{Actors: stats, Files: fileStats, Metadata: &Metadata{Mode: "estimate", Approximate: true, Revision: config.Revision}}
+	}
+
+	stats, fileStats := aggregateStats(filteredFiles, config)
+	span = config.tracer.start("aggregate", nil)
+	defer span.finish()
+	return finishStats(stats, fileStats, config)
+}
+
+// finishStats adds submodules and the derived columns to blamed stats.
+func finishStats(stats map[string]ActorStats, fileStats []FileStats, config Config) Report {
+	fileStats = addSubmoduleStats(stats, fileStats, config)
+	computeVelocity(stats, config)
+	computeTrend(stats, config)
+	computeKnowledge(stats, config)
+	computeSurvival(stats, config)
+	computeAge(stats, config)
+	computeDepth(stats, fileStats, config)
+	computeAreas(stats, fileStats, config)
+	return Report{Actors: stats, Files: fileStats}
+}
+
 func validateConfig(config *Config, flags *pflag.FlagSet) {
-	validFormats := map[string]bool{"tabular": true, "csv": true, "json": true, "json-lines": true}
+	if err := loadConfigFiles(config, flags); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config file: %v\n", err)
+		os.Exit(2)
+	}
+
+	validFormats := map[string]bool{"tabular": true, "csv": true, "json": true, "json-lines": true, "gh-summary": true, "influx": true, "chart": true}
 	if _, ok := validFormats[config.Format]; !ok {
 		fmt.Fprintf(os.Stderr, "Invalid format: %s\n", config.Format)
 		os.Exit(2)
 	}
 
-	validOrders := map[string]bool{"lines": true, "commits": true, "files": true}
-	if _, ok := validOrders[config.OrderBy]; !ok {
-		fmt.Fprintf(os.Stderr, "Invalid order-by value: %s\n", config.OrderBy)
+	if config.BlameStrategy != "porcelain" && config.BlameStrategy != "incremental" {
+		fmt.Fprintf(os.Stderr, "Invalid blame strategy: %s\n", config.BlameStrategy)
+		os.Exit(2)
+	}
+	if config.Count != "all" && config.Count != "code" {
+		fmt.Fprintf(os.Stderr, "Invalid count: %s\n", config.Count)
+		os.Exit(2)
+	}
+	if (config.Count == "code" || config.ExcludeLicenses) && config.Mode == "fast" {
+		fmt.Fprintf(os.Stderr, "--count=code and --exclude-license-headers are not supported in fast mode\n")
 		os.Exit(2)
 	}
 
-	cmd := exec.Command("git", "-C", config.Repository, "cat-file", "-e", config.Revision)
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
+	seenOrders := make(map[string]bool)
+	for _, key := range strings.Split(config.OrderBy, ",") {
+		if _, ok := orderKeys[key]; !ok || seenOrders[key] {
+			fmt.Fprintf(os.Stderr, "Invalid order-by value: %s\n", config.OrderBy)
+			os.Exit(2)
+		}
+		seenOrders[key] = true
+	}
+	if _, err := selectColumns(*config); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid column: %v\n", err)
+		os.Exit(2)
+	}
+	if config.Metric != "lines" && config.Metric != "commits" && config.Metric != "files" {
+		fmt.Fprintf(os.Stderr, "Invalid metric: %s\n", config.Metric)
+		os.Exit(2)
+	}
+	if config.Color != "auto" && config.Color != "always" && config.Color != "never" {
+		fmt.Fprintf(os.Stderr, "Invalid color: %s\n", config.Color)
+		os.Exit(2)
+	}
+	if _, err := parsePercent(config.DimBelow); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid dim-below threshold: %s\n", config.DimBelow)
+		os.Exit(2)
+	}
+	if config.MaxColumnWidth < 0 || config.MaxColumnWidth == 1 {
+		fmt.Fprintf(os.Stderr, "Invalid max column width: %d\n", config.MaxColumnWidth)
+		os.Exit(2)
+	}
+	if config.Sort != "" && config.Sort != "asc" && config.Sort != "desc" {
+		fmt.Fprintf(os.Stderr, "Invalid sort direction: %s\n", config.Sort)
+		os.Exit(2)
+	}
+	if config.SlackBaseline != "" && config.NotifySlack == "" {
+		fmt.Fprintf(os.Stderr, "--slack-baseline requires --notify-slack\n")
+		os.Exit(2)
+	}
+	if len(config.PublishHeaders) > 0 && config.PublishURL == "" {
+		fmt.Fprintf(os.Stderr, "--publish-header requires --publish-url\n")
+		os.Exit(2)
+	}
+	for _, header := range config.PublishHeaders {
+		if name, _, ok := strings.Cut(header, ":"); !ok || strings.TrimSpace(name) == "" {
+			fmt.Fprintf(os.Stderr, "Invalid publish header: %s\n", header)
+			os.Exit(2)
+		}
+	}
+	if config.PublishURL != "" {
+		if u, err := url.Parse(config.PublishURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			fmt.Fprintf(os.Stderr, "Invalid publish url: %s\n", config.PublishURL)
+			os.Exit(2)
+		}
+	}
+	if config.OtelEndpoint != "" {
+		if u, err := url.Parse(config.OtelEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			fmt.Fprintf(os.Stderr, "Invalid otel endpoint: %s\n", config.OtelEndpoint)
+			os.Exit(2)
+		}
+	}
+	if config.Stats != "" && config.Stats != "text" && config.Stats != "json" {
+		fmt.Fprintf(os.Stderr, "Invalid stats format: %s\n", config.Stats)
+		os.Exit(2)
+	}
+	if config.OtelEndpoint != "" || config.Stats != "" {
+		config.tracer = newTracer(config.OtelEndpoint, config.Stats)
+	}
+	if config.GithubToken != "" && config.GitlabToken != "" {
+		fmt.Fprintf(os.Stderr, "--github-token is not supported with --gitlab-token\n")
+		os.Exit(2)
+	}
 
-	if err := cmd.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Invalid revision: %s\n", config.Revision)
+	for _, key := range config.TieBreak {
+		if key != "name" && key != "email" {
+			fmt.Fprintf(os.Stderr, "Invalid tie-break key: %s\n", key)
+			os.Exit(2)
+		}
+	}
+
+	for i, ext := range config.Extensions {
+		config.Extensions[i] = normalizeExtension(ext)
+	}
+
+	validModes := map[string]bool{"blame": true, "fast": true}
+	if _, ok := validModes[config.Mode]; !ok {
+		fmt.Fprintf(os.Stderr, "Invalid mode: %s\n", config.Mode)
+		os.Exit(2)
+	}
+
+	validPathStyles := map[string]bool{"relative": true, "absolute": true, "uri": true}
+	if _, ok := validPathStyles[config.PathStyle]; !ok {
+		fmt.Fprintf(os.Stderr, "Invalid path-style: %s\n", config.PathStyle)
+		os.Exit(2)
+	}
+
+	for _, v := range config.Velocity {
+		w, err := parseWindow(v)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid velocity window: %s\n", v)
+			os.Exit(2)
+		}
+		config.windows = append(config.windows, w)
+	}
+	period, err := parseWindow(config.SparklinePeriod)
+	if err != nil || config.Sparkline < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid sparkline: %d of %s\n", config.Sparkline, config.SparklinePeriod)
+		os.Exit(2)
+	}
+	config.sparklinePeriod = period
+	if config.TopAreas < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid top-areas: %d\n", config.TopAreas)
+		os.Exit(2)
+	}
+	halfLife, err := parseWindow(config.KnowledgeHalfLife)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid knowledge half-life: %s\n", config.KnowledgeHalfLife)
+		os.Exit(2)
+	}
+	config.knowledgeHalfLife = halfLife
+	if config.Knowledge && (config.Mode == "fast" || config.Estimate) {
+		fmt.Fprintf(os.Stderr, "--knowledge is not supported in fast mode or with --estimate\n")
+		os.Exit(2)
+	}
+	if config.Weighted && (config.Mode == "fast" || config.Estimate) {
+		fmt.Fprintf(os.Stderr, "--weighted is not supported in fast mode or with --estimate\n")
+		os.Exit(2)
+	}
+	if config.Sparkline > 0 && (config.Mode == "fast" || config.Estimate) {
+		fmt.Fprintf(os.Stderr, "--sparkline is not supported in fast mode or with --estimate\n")
+		os.Exit(2)
+	}
+	if len(config.windows) > 0 && config.Mode == "fast" {
+		fmt.Fprintf(os.Stderr, "--velocity is not supported in fast mode\n")
+		os.Exit(2)
+	}
+	if config.Survival && config.Mode == "fast" {
+		fmt.Fprintf(os.Stderr, "--survival is not supported in fast mode\n")
+		os.Exit(2)
+	}
+	if config.ShowRoles && config.Mode == "fast" {
+		fmt.Fprintf(os.Stderr, "--show-roles is not supported in fast mode\n")
+		os.Exit(2)
+	}
+	if config.Age && config.Mode == "fast" {
+		fmt.Fprintf(os.Stderr, "--age is not supported in fast mode\n")
+		os.Exit(2)
+	}
+	if config.Activity && config.Mode == "fast" {
+		fmt.Fprintf(os.Stderr, "--activity is not supported in fast mode\n")
+		os.Exit(2)
+	}
+	if config.Estimate {
+		share, err := parsePercent(config.SampleFiles)
+		if err != nil || share <= 0 || share > 100 {
+			fmt.Fprintf(os.Stderr, "Invalid sample-files: %s\n", config.SampleFiles)
+			os.Exit(2)
+		}
+		if config.Mode == "fast" {
+			fmt.Fprintf(os.Stderr, "--estimate is not supported in fast mode\n")
+			os.Exit(2)
+		}
+		if len(config.windows) > 0 || config.Survival {
+			fmt.Fprintf(os.Stderr, "--velocity and --survival are not supported with --estimate\n")
+			os.Exit(2)
+		}
+	}
+	if config.RecurseSubmodules && config.Survival {
+		fmt.Fprintf(os.Stderr, "--survival is not supported with --recurse-submodules\n")
+		os.Exit(2)
+	}
+	if config.EOLReport && config.Mode == "fast" {
+		fmt.Fprintf(os.Stderr, "--eol-report is not supported in fast mode\n")
+		os.Exit(2)
+	}
+
+	if config.NoOwnerThreshold != "" {
+		if _, err := parsePercent(config.NoOwnerThreshold); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid no-owner-threshold: %s\n", config.NoOwnerThreshold)
+			os.Exit(2)
+		}
+	}
+
+	validDetects := map[string]bool{"extension": true, "content": true}
+	if _, ok := validDetects[config.Detect]; !ok {
+		fmt.Fprintf(os.Stderr, "Invalid detect strategy: %s\n", config.Detect)
+		os.Exit(2)
+	}
+	config.content = &contentCache{}
+	config.attributes = &attributeCache{}
+
+	if _, ok := breakdownHeaders[config.Breakdown]; !ok && config.Breakdown != "" {
+		fmt.Fprintf(os.Stderr, "Invalid breakdown: %s\n", config.Breakdown)
+		os.Exit(2)
+	}
+	if config.Breakdown != "" && config.ValidateOutput {
+		fmt.Fprintf(os.Stderr, "--validate-output is not supported with --breakdown\n")
+		os.Exit(2)
+	}
+	if config.OwnerAbove != "" {
+		if _, err := parsePercent(config.OwnerAbove); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid owner-above: %s\n", config.OwnerAbove)
+			os.Exit(2)
+		}
+		if !config.ByFile {
+			fmt.Fprintf(os.Stderr, "--owner-above requires --by-file\n")
+			os.Exit(2)
+		}
+	}
+	if config.Anonymize && config.AnonymizeSalt == "" {
+		fmt.Fprintf(os.Stderr, "--anonymize requires --anonymize-salt or GITFAME_ANONYMIZE_SALT\n")
+		os.Exit(2)
+	}
+	if config.Anonymize && (config.GithubToken != "" || config.GitlabToken != "") {
+		fmt.Fprintf(os.Stderr, "--anonymize is not supported with forge logins\n")
+		os.Exit(2)
+	}
+	if config.ByFileSort != "path" && config.ByFileSort != "entropy" {
+		fmt.Fprintf(os.Stderr, "Invalid by-file-sort: %s\n", config.ByFileSort)
+		os.Exit(2)
+	}
+	if config.ByFile && (config.Breakdown != "" || config.ShowFiles || config.ValidateOutput) {
+		fmt.Fprintf(os.Stderr, "--by-file is not supported with --breakdown, --show-files and --validate-output\n")
+		os.Exit(2)
+	}
+
+	if config.Jobs < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid jobs value: %d\n", config.Jobs)
+		os.Exit(2)
+	}
+	if config.Jobs == 0 {
+		config.Jobs = defaultJobs()
+	}
+
+	if config.MaxFileSize != "" {
+		size, err := parseSize(config.MaxFileSize)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid max-file-size: %s\n", config.MaxFileSize)
+			os.Exit(2)
+		}
+		config.maxFileSize = size
+	}
+
+	for _, name := range config.Presets {
+		patterns, ok := configs.PresetExcludes(name)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Invalid preset: %s\n", name)
+			os.Exit(2)
+		}
+		config.Exclude = append(config.Exclude, patterns...)
+	}
+
+	if config.ExcludeFrom != "" {
+		patterns, err := configs.LoadListFile(config.ExcludeFrom)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid exclude file: %v\n", err)
+			os.Exit(2)
+		}
+		config.Exclude = append(config.Exclude, patterns...)
+	}
+
+	if config.FilesFrom != "" {
+		files, err := readFilesFrom(config.FilesFrom)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid files-from: %v\n", err)
+			os.Exit(2)
+		}
+		config.filesFrom = files
+	}
+
+	patterns := append(append([]string{}, config.Exclude...), config.RestrictTo...)
+	for _, override := range config.File.Languages {
+		patterns = append(patterns, override.Exclude...)
+	}
+	for _, set := range config.File.AccessReview {
+		patterns = append(patterns, set.Paths...)
+	}
+	for sink := range config.File.Redaction {
+		if !redactionSinks[sink] {
+			fmt.Fprintf(os.Stderr, "Invalid redaction sink: %s\n", sink)
+			os.Exit(2)
+		}
+	}
+	if _, ok := config.File.Redaction[config.Format]; ok && config.ValidateOutput {
+		fmt.Fprintf(os.Stderr, "--validate-output is not supported with a redaction policy for %s\n", config.Format)
+		os.Exit(2)
+	}
+	if config.AccessReview != "" && len(config.File.AccessReview) == 0 {
+		fmt.Fprintf(os.Stderr, "--access-review requires access_review path sets in --config\n")
+		os.Exit(2)
+	}
+	for _, pattern := range patterns {
+		if !doublestar.ValidatePattern(pattern) {
+			fmt.Fprintf(os.Stderr, "Invalid pattern: %s\n", pattern)
+			os.Exit(2)
+		}
+	}
+
+	if _, ok := compressionSuffixes[config.Compress]; !ok && config.Compress != "" {
+		fmt.Fprintf(os.Stderr, "Invalid compression: %s\n", config.Compress)
+		os.Exit(2)
+	}
+	if config.Compress == "zstd" {
+		if _, err := exec.LookPath("zstd"); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid compression: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	if config.Resume && config.Checkpoint == "" {
+		fmt.Fprintf(os.Stderr, "--resume requires --checkpoint\n")
+		os.Exit(2)
+	}
+	if config.Checkpoint != "" && config.Mode == "fast" {
+		fmt.Fprintf(os.Stderr, "--checkpoint is not supported in fast mode\n")
+		os.Exit(2)
+	}
+	if (config.All || config.Branches != "") && (config.Mode == "fast" || config.Estimate || config.Checkpoint != "" || config.Incremental != "") {
+		fmt.Fprintf(os.Stderr, "--all and --branches are not supported in fast mode or with --estimate, --checkpoint and --incremental\n")
+		os.Exit(2)
+	}
+	if config.Incremental != "" && (config.Mode == "fast" || config.Estimate) {
+		fmt.Fprintf(os.Stderr, "--incremental is not supported in fast mode or with --estimate\n")
+		os.Exit(2)
+	}
+
+	if config.FailIfTopOwnerAbove != "" {
+		if _, err := parsePercent(config.FailIfTopOwnerAbove); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid top owner threshold: %s\n", config.FailIfTopOwnerAbove)
+			os.Exit(2)
+		}
+	}
+	if config.FailIfBusFactorBelow < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid bus factor threshold: %d\n", config.FailIfBusFactorBelow)
+		os.Exit(2)
+	}
+
+	if config.CloneDepth < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid clone depth: %d\n", config.CloneDepth)
 		os.Exit(2)
 	}
 }
 
-func getFiles(config Config) []string {
-	cmd := exec.Command("git", "-C", config.Repository, "ls-tree", "-r", "--name-only", config.Revision)
+// reportFlags act on the report of gitfame, merge and org; the other
+// subcommands print tables of their own and reject them.
+var reportFlags = []string{
+	"publish-url", "notify-slack", "partial-output", "access-review", "validate-output",
+	"fail-if-top-owner-above", "fail-if-bus-factor-below",
+}
+
+func rejectReportFlags(cmd *cobra.Command) {
+	name := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	for _, flag := range reportFlags {
+		if cmd.Flags().Changed(flag) {
+			fmt.Fprintf(os.Stderr, "--%s is not supported by %s\n", flag, name)
+			os.Exit(2)
+		}
+	}
+}
+
+// prepareRepositories resolves and clones the analyzed repositories and
+// validates the options that depend on their history.
+func prepareRepositories(config *Config, flags *pflag.FlagSet) {
+	if config.ReposFile != "" {
+		repos, err := configs.LoadListFile(config.ReposFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid repos file: %v\n", err)
+			os.Exit(2)
+		}
+		if !flags.Changed("repository") {
+			config.Repositories = nil
+		}
+		config.Repositories = append(config.Repositories, repos...)
+	}
+	if len(config.Repositories) == 0 {
+		fmt.Fprintf(os.Stderr, "Invalid repository: none given\n")
+		os.Exit(2)
+	}
+	repositories, err := resolveRepositories(*config)
+	if err != nil {
+		removeClones()
+		fmt.Fprintf(os.Stderr, "Invalid repository: %v\n", err)
+		os.Exit(2)
+	}
+	config.repositories = repositories
+	config.Repository = repositories[0].Path
+
+	config.revisionName = config.Revision
+	for _, r := range config.repositories {
+		sub := *config
+		sub.Repository = r.Path
+		commit, ok := resolveRevision(sub, config.Revision)
+		if !ok {
+			removeClones()
+			fmt.Fprintf(os.Stderr, "Invalid revision: %s\n", config.Revision)
+			os.Exit(2)
+		}
+		// Every repository resolves the revision for itself in collectStats.
+		if len(config.repositories) == 1 {
+			config.Revision = commit
+		}
+	}
+
+	if config.All || config.Branches != "" {
+		for _, r := range config.repositories {
+			sub := *config
+			sub.Repository = r.Path
+			if len(branchTips(sub)) == 0 {
+				removeClones()
+				fmt.Fprintf(os.Stderr, "Invalid branches: no branch matches %q\n", config.Branches)
+				os.Exit(2)
+			}
+		}
+	}
+
+	if config.SlackBaseline != "" {
+		for _, r := range config.repositories {
+			sub := *config
+			sub.Repository = r.Path
+			if !revisionExists(sub, config.SlackBaseline) {
+				removeClones()
+				fmt.Fprintf(os.Stderr, "Invalid slack baseline: %s\n", config.SlackBaseline)
+				os.Exit(2)
+			}
+		}
+	}
+
+	if config.ImportBoundary != "" {
+		if !revisionExists(*config, config.ImportBoundary) {
+			removeClones()
+			fmt.Fprintf(os.Stderr, "Invalid import boundary: %s\n", config.ImportBoundary)
+			os.Exit(2)
+		}
+		imported, err := importedCommits(*config)
+		if err != nil {
+			removeClones()
+			fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git rev-list: %v\n", err)
+			os.Exit(1)
+		}
+		config.imported = imported
+	}
+
+	validForges := map[string]bool{"": true, "github": true}
+	if _, ok := validForges[config.ForgeAttribution]; !ok {
+		removeClones()
+		fmt.Fprintf(os.Stderr, "Invalid forge attribution: %s\n", config.ForgeAttribution)
+		os.Exit(2)
+	}
+	if config.ForgeAttribution != "" {
+		if config.Mode == "fast" {
+			removeClones()
+			fmt.Fprintf(os.Stderr, "--forge-attribution is not supported in fast mode\n")
+			os.Exit(2)
+		}
+		if config.UseCommitter {
+			removeClones()
+			fmt.Fprintf(os.Stderr, "--forge-attribution is not supported with --use-committer\n")
+			os.Exit(2)
+		}
+		forge, err := newGithubAttribution(*config)
+		if err != nil {
+			removeClones()
+			fmt.Fprintf(os.Stderr, "Invalid forge attribution: %v\n", err)
+			os.Exit(2)
+		}
+		config.forge = forge
+	}
+
+	if config.Checkpoint != "" {
+		c, err := openCheckpoint(*config)
+		if err != nil {
+			removeClones()
+			fmt.Fprintf(os.Stderr, "Invalid checkpoint: %v\n", err)
+			os.Exit(2)
+		}
+		config.checkpoint = c
+	}
+
+	if config.Incremental != "" {
+		if len(config.repositories) > 1 {
+			removeClones()
+			fmt.Fprintf(os.Stderr, "--incremental is not supported with several repositories\n")
+			os.Exit(2)
+		}
+		s, err := openIncremental(*config)
+		if err != nil {
+			removeClones()
+			fmt.Fprintf(os.Stderr, "Invalid incremental state: %v\n", err)
+			os.Exit(2)
+		}
+		config.incremental = s
+	}
+}
+
+func revisionExists(config Config, revision string) bool {
+	_, ok := resolveRevision(config, revision)
+	return ok
+}
+
+// resolveRevision returns the commit a revision expression names: a branch,
+// a tag, HEAD~3, :/message and so on. Revisions starting with a dash are not
+// taken for options.
+func resolveRevision(config Config, revision string) (string, bool) {
+	out, err := gitCommand(config, "rev-parse", "--verify", "--quiet", "--end-of-options", revision).Output()
+	if err != nil {
+		return "", false
+	}
+	// :/message takes the rest of the expression, so the object is peeled
+	// separately.
+	out, err = gitCommand(config, "rev-parse", "--verify", "--quiet", strings.TrimSpace(string(out))+"^{commit}").Output()
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(out)), true
+}
+
+// treeEntry is a line of `git ls-tree -r` output.
+type treeEntry struct {
+	Mode   string
+	Type   string
+	Object string
+	Path   string
+}
+
+// Modes of tree entries that are not regular files.
+const (
+	symlinkMode = "120000"
+	gitlinkMode = "160000"
+)
+
+func listTree(config Config) []treeEntry {
+	// Entries are NUL-terminated, so paths are not quoted.
+	cmd := gitCommand(config, append([]string{"ls-tree", "-r", "-z", config.Revision, "--"}, config.Paths...)...)
 	var stdout bytes.Buffer
 	cmd.Stdout = &stdout
 
@@ -114,19 +997,32 @@ func getFiles(config Config) []string {
 		return nil
 	}
 
-	var files []string
-
-	scanner := bufio.NewScanner(&stdout)
-	for scanner.Scan() {
-		file := scanner.Text()
-		files = append(files, file)
+	var entries []treeEntry
+	for _, line := range strings.Split(stdout.String(), "\x00") {
+		meta, file, ok := strings.Cut(line, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 {
+			continue
+		}
+		entries = append(entries, treeEntry{Mode: fields[0], Type: fields[1], Object: fields[2], Path: file})
 	}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Fprintf(os.Stderr, "Ошибка чтения вывода команды git ls-tree: %v\n", err)
-		return nil
-	}
+	return entries
+}
 
+// getFiles returns the regular files of the revision. Symlinks and
+// submodules are not blamed: their blobs are a path and a commit id.
+func getFiles(config Config) []string {
+	var files []string
+	for _, entry := range listTree(config) {
+		if entry.Type != "blob" || entry.Mode == symlinkMode {
+			continue
+		}
+		if config.filesFrom != nil && !config.filesFrom[entry.Path] {
+			continue
+		}
+		files = append(files, entry.Path)
+	}
 	return files
 }
 
@@ -136,20 +1032,47 @@ func matchesExtensions(file string, extensions []string) bool {
 	}
 
 	for _, ext := range extensions {
-		if strings.HasSuffix(file, ext) {
+		if hasExtension(file, ext) {
 			return true
 		}
 	}
 	return false
 }
 
+// normalizeExtension turns "go", ".go" and "GO" into ".go".
+func normalizeExtension(ext string) string {
+	return "." + strings.TrimPrefix(strings.ToLower(strings.TrimSpace(ext)), ".")
+}
+
+// hasExtension matches a normalized extension against the end of the base
+// name, so that multi-part extensions like ".d.ts" work too.
+func hasExtension(file, ext string) bool {
+	base := strings.ToLower(filepath.Base(file))
+	return len(base) > len(ext) && strings.HasSuffix(base, ext)
+}
+
+func warnUnmatchedExtensions(files []string, config Config) {
+	for _, ext := range config.Extensions {
+		matched := false
+		for _, file := range files {
+			if hasExtension(file, ext) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fmt.Fprintf(os.Stderr, "Warning: extension %s matches no files\n", ext)
+		}
+	}
+}
+
 func matchesExcludePatterns(file string, patterns []string) bool {
 	if len(patterns) == 0 {
 		return true
 	}
 
 	for _, pattern := range patterns {
-		matched, _ := filepath.Match(pattern, file)
+		matched, _ := doublestar.Match(pattern, file)
 		if matched {
 			return false
 		}
@@ -163,7 +1086,7 @@ func matchesRestrictToPatterns(file string, patterns []string) bool {
 	}
 
 	for _, pattern := range patterns {
-		matched, _ := filepath.Match(pattern, file)
+		matched, _ := doublestar.Match(pattern, file)
 		if matched {
 			return true
 		}
@@ -176,13 +1099,22 @@ func matchesLanguage(filePath string, config Config) bool {
 		return true
 	}
 	fileExtension := filepath.Ext(filePath)
+	if config.Detect == "content" || fileExtension == "" || attributeLanguage(filePath, config) != "" {
+		return matchesDetectedLanguage(filePath, config)
+	}
 
 	for _, allowedLang := range config.Languages {
 		allowedLang = strings.ToLower(allowedLang)
+		if matchesFilename(filePath, allowedLang, config) && !excludedByLanguageOverride(filePath, allowedLang, config) {
+			return true
+		}
 		if existedLang, ok := config.ExtensionsMap[allowedLang]; ok {
 			for _, allowedExtension := range existedLang {
 
 				if strings.HasSuffix(fileExtension, allowedExtension) {
+					if excludedByLanguageOverride(filePath, allowedLang, config) {
+						break
+					}
 					return true
 				}
 			}
@@ -192,18 +1124,33 @@ func matchesLanguage(filePath string, config Config) bool {
 	return false
 }
 
+// excludedByLanguageOverride applies the excludes configured for a language
+// in the config file. Patterns without a slash match the base name.
+func excludedByLanguageOverride(filePath, language string, config Config) bool {
+	for _, pattern := range config.File.Languages[language].Exclude {
+		name := filePath
+		if !strings.Contains(pattern, "/") {
+			name = filepath.Base(filePath)
+		}
+		if matched, _ := doublestar.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
 func parallelFilter(files []string, config Config) chan string {
 	var filterWg sync.WaitGroup
 	filteredChan := make(chan string, len(files))
+	span := config.tracer.start("filter", nil)
+	config.tracer.count("scanned", len(files))
 
 	for _, file := range files {
 		filterWg.Add(1)
 		go func(file string) {
 			defer filterWg.Done()
-			if matchesExtensions(file, config.Extensions) &&
-				matchesExcludePatterns(file, config.Exclude) &&
-				matchesRestrictToPatterns(file, config.RestrictTo) &&
-				matchesLanguage(file, config) {
+			if matchesFilters(file, config) {
+				config.tracer.count("filtered", 1)
 				filteredChan <- file
 			}
 		}(file)
@@ -211,13 +1158,24 @@ func parallelFilter(files []string, config Config) chan string {
 	go func() {
 		filterWg.Wait()
 		close(filteredChan)
+		span.finish()
 	}()
 
 	return filteredChan
 }
 
+func matchesFilters(file string, config Config) bool {
+	return matchesExtensions(file, config.Extensions) &&
+		matchesExcludePatterns(file, config.Exclude) &&
+		matchesRestrictToPatterns(file, config.RestrictTo) &&
+		matchesLanguage(file, config) &&
+		!excludedByAttributes(file, config) &&
+		!excludedByDefault(file, config) &&
+		!(config.ExcludeGenerated && isGenerated(file, config))
+}
+
 func infoEmptyFile(file string, config Config) ActorStats {
-	cmd := exec.Command("git", "-C", config.Repository, "log", "-n", "1", "--pretty=format:%H\n%an", config.Revision, "--", file)
+	cmd := gitCommand(config, "log", "-n", "1", "--pretty=format:%H\n%an", config.Revision, "--", file)
 	var out bytes.Buffer
 	cmd.Stdout = &out
 
@@ -228,6 +1186,9 @@ func infoEmptyFile(file string, config Config) ActorStats {
 
 	line := strings.Split(out.String(), "\n")
 	commitHash, actor := line[0], line[1]
+	if isImported(commitHash, config) {
+		actor = importedActor
+	}
 	stats := ActorStats{
 		Name:       actor,
 		Files:      1,
@@ -238,72 +1199,260 @@ func infoEmptyFile(file string, config Config) ActorStats {
 	return stats
 }
 
-func calculateStats(file string, config Config) map[string]ActorStats {
-	cmd := exec.Command("git", "-C", config.Repository, "blame", "--line-porcelain", file, config.Revision)
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	err := cmd.Run()
-	if err != nil {
-		return nil
+// calculateStats blames the file and also returns its eolStyle.
+func calculateStats(file string, config Config) (map[string]ActorStats, string) {
+	actorStats := make(map[string]ActorStats)
+
+	// Empty files have nothing to blame.
+	if size, ok := blobSize(file, config); ok && size == 0 {
+		stats := infoEmptyFile(file, config)
+		actorStats[stats.Name] = stats
+		return actorStats, ""
 	}
 
-	actorStats := make(map[string]ActorStats)
+	var out string
+	var lines []string
+	forEachGroup := func(fn func(g blameGroup)) { forEachBlameGroup(out, config, fn) }
+	if config.BlameStrategy == "incremental" {
+		var ok bool
+		if out, lines, ok = incrementalBlame(file, config); !ok {
+			return nil, ""
+		}
+		forEachGroup = func(fn func(g blameGroup)) { forEachIncrementalGroup(out, lines, config, fn) }
+	} else {
+		cmd := gitCommand(config, "blame", "--line-porcelain", file, config.Revision)
+		var buf bytes.Buffer
+		cmd.Stdout = &buf
+		if err := cmd.Run(); err != nil {
+			return nil, ""
+		}
+		out = buf.String()
+	}
 
-	if out.Len() == 0 {
+	if len(out) == 0 {
 		stats := infoEmptyFile(file, config)
 		actorStats[stats.Name] = stats
-		return actorStats
+		return actorStats, ""
+	}
+
+	if config.BlameStrategy != "incremental" {
+		lines = blameContent(out)
+	}
+	kinds, weights := fileKinds(file, lines, config), lineWeights(file, lines, config)
+	forEachGroup(func(g blameGroup) {
+		classifyGroup(&g, kinds, weights, config)
+		addBlameGroup(actorStats, g, config)
+	})
+	return actorStats, eolStyleOf(lines)
+}
+
+// addBlameGroup adds the lines of the group to the stats of a file.
+func addBlameGroup(actorStats map[string]ActorStats, g blameGroup, config Config) {
+	if _, ok := actorStats[g.Actor]; !ok {
+		actorStats[g.Actor] = ActorStats{
+			Files:       1,
+			commitsSet:  make(map[string]struct{}),
+			authorTimes: make(map[int64]int),
+			roles:       make(map[string]int),
+			emails:      make(map[string]int),
+		}
+	}
+	stats := actorStats[g.Actor]
+	stats.Lines += g.Lines
+	stats.code += g.Code
+	stats.comments += g.Comments
+	stats.blank += g.Blank
+	stats.weighted += g.Weighted
+	stats.Name = g.Actor
+	stats.commitsSet[g.Commit] = struct{}{}
+	stats.authorTimes[g.AuthorTime] += g.Lines
+	stats.emails[g.ActorMail] += g.Lines
+	if config.UseCommitter {
+		stats.roles[g.Author] += g.Lines
+	} else {
+		stats.roles[g.Committer] += g.Lines
+	}
+	actorStats[g.Actor] = stats
+}
+
+type blameGroup struct {
+	Commit     string
+	Actor      string
+	Author     string
+	Committer  string
+	ActorMail  string
+	AuthorTime int64
+	Lines      int
+	// Filename, OrigLine and Count locate the lines in the blamed commit;
+	// Lines also counts the lone CRs in them.
+	Filename  string
+	OrigLine  int
+	Count     int
+	FinalLine int
+	// Code, Comments, Blank and License count the lines by kind, see
+	// classifyGroup.
+	Code     int
+	Comments int
+	Blank    int
+	License  int
+	// Weighted sums the --weighted weights of the credited lines.
+	Weighted int
+}
+
+// parseGroupHeader recognizes the "<hash> <orig> <final> <count>" line that
+// starts a group in porcelain output and returns the hash, with the boundary
+// mark, the first line numbers in the original and the final file and the
+// line count. Other lines never start with 40 hex digits and a
+// space, so the check stays cheap on the content lines.
+func parseGroupHeader(line string) (string, int, int, int, bool) {
+	hash := line
+	if strings.HasPrefix(hash, "^") {
+		hash = hash[1:]
+	}
+	if len(hash) < 41 || hash[40] != ' ' {
+		return "", 0, 0, 0, false
+	}
+	for i := 0; i < 40; i++ {
+		if c := hash[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return "", 0, 0, 0, false
+		}
+	}
+
+	rest := hash[41:]
+	var numbers [3]int
+	for field := range numbers {
+		end := 0
+		for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+			end++
+		}
+		if end == 0 || field < 2 && (end == len(rest) || rest[end] != ' ') {
+			return "", 0, 0, 0, false
+		}
+		n, err := strconv.Atoi(rest[:end])
+		if err != nil {
+			return "", 0, 0, 0, false
+		}
+		numbers[field] = n
+		if field < 2 {
+			rest = rest[end+1:]
+		}
 	}
+	return line[:len(line)-len(hash)+40], numbers[0], numbers[1], numbers[2], true
+}
 
-	lines := strings.Split(out.String(), "\n")
-	commitLineRegexp := regexp.MustCompile(`^\^?[a-f0-9]{40} \d+ \d+ \d+`)
+// forEachBlameGroup calls fn for every group of consecutive lines from the
+// same commit in `git blame --line-porcelain` output.
+func forEachBlameGroup(out string, config Config, fn func(g blameGroup)) {
+	lines := strings.Split(out, "\n")
 
 	for i := 0; i < len(lines); i++ {
-		if commitLineRegexp.MatchString(lines[i]) {
-			parts := strings.Split(lines[i], " ")
-			commitHash := parts[0]
-			nLines, err := strconv.Atoi(parts[3])
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Ошибка преобразования: %v\n", err)
-				i++
-				continue
-			}
-			actor := strings.TrimPrefix(lines[i+1], "author ")
-			if config.UseCommitter {
-				actor = strings.TrimPrefix(lines[i+5], "committer ")
-			}
+		if commitHash, origLine, finalLine, count, ok := parseGroupHeader(lines[i]); ok {
+			nLines := count
+			author := strings.TrimPrefix(lines[i+1], "author ")
+			committer := strings.TrimPrefix(lines[i+5], "committer ")
+			actor, actorMail := groupActor(commitHash, author, strings.TrimPrefix(lines[i+2], "author-mail "),
+				committer, strings.TrimPrefix(lines[i+6], "committer-mail "), config)
+			authorTime, _ := strconv.ParseInt(strings.TrimPrefix(lines[i+3], "author-time "), 10, 64)
 
-			if _, ok := actorStats[actor]; !ok {
-				actorStats[actor] = ActorStats{
-					Files:      1,
-					commitsSet: make(map[string]struct{}),
+			// Lone CRs inside the group's lines count as extra lines.
+			var filename string
+			for j := i + 1; j < len(lines); j++ {
+				if _, _, _, _, ok := parseGroupHeader(lines[j]); ok {
+					break
+				}
+				if content, ok := strings.CutPrefix(lines[j], "\t"); ok {
+					nLines += blameContentLines(content) - 1
+				} else if name, ok := strings.CutPrefix(lines[j], "filename "); ok && filename == "" {
+					filename = name
 				}
 			}
-			stats := actorStats[actor]
-			stats.Lines += nLines
-			stats.Name = actor
-			stats.commitsSet[commitHash] = struct{}{}
-			actorStats[actor] = stats
+
+			fn(blameGroup{
+				Commit:     commitHash,
+				Actor:      actor,
+				Author:     author,
+				Committer:  committer,
+				ActorMail:  strings.Trim(actorMail, "<>"),
+				AuthorTime: authorTime,
+				Lines:      nLines,
+				Filename:   filename,
+				OrigLine:   origLine,
+				Count:      count,
+				FinalLine:  finalLine,
+			})
 		}
 	}
+}
 
-	return actorStats
+// groupActor returns the actor credited with the lines of the commit and their
+// email in angle brackets.
+func groupActor(commitHash, author, authorMail, committer, committerMail string, config Config) (string, string) {
+	actor, actorMail := author, authorMail
+	if config.UseCommitter {
+		actor, actorMail = committer, committerMail
+	}
+	if isImported(commitHash, config) {
+		actor, actorMail = importedActor, ""
+	} else if config.forge != nil {
+		if a, ok := config.forge.author(strings.TrimPrefix(commitHash, "^"), actor); ok {
+			actor, actorMail = a.Name, "<"+a.Email+">"
+		}
+	}
+	return actor, actorMail
 }
 
-func aggregateStats(files chan string, config Config) map[string]ActorStats {
+func aggregateStats(files chan string, config Config) (map[string]ActorStats, []FileStats) {
+	type fileResult struct {
+		path  string
+		stats map[string]ActorStats
+		eol   string
+	}
+
 	var aggWg sync.WaitGroup
-	resultsChan := make(chan map[string]ActorStats)
+	resultsChan := make(chan fileResult, config.Jobs)
 
 	finalStats := make(map[string]ActorStats)
+	var fileStats []FileStats
 
-	for file := range files {
+	var revision string
+	if config.checkpoint != nil || config.incremental != nil {
+		revision = revisionHash(config, config.Revision)
+	}
+
+	blobs := newBlobCache(config)
+
+	stage := config.tracer.start("blame", nil)
+	defer stage.finish()
+
+	for w := 0; w < config.Jobs; w++ {
 		aggWg.Add(1)
-		go func(file string) {
+		go func() {
 			defer aggWg.Done()
 
-			fileStats := calculateStats(file, config)
-			resultsChan <- fileStats
-		}(file)
+			for file := range files {
+				span := config.tracer.start("blame-file", stage, "file", file)
+				var unchanged bool
+				stats, eol, reused := blobs.stats(file, func(file string) (map[string]ActorStats, string) {
+					object := blobs.objects[file]
+					if stats, eol, ok := config.incremental.stats(file, object); ok {
+						unchanged = true
+						return stats, eol
+					}
+					stats, eol := checkpointedStats(file, revision, config)
+					config.incremental.record(file, object, stats, eol)
+					return stats, eol
+				})
+				span.finish()
+				if reused || unchanged {
+					config.tracer.count("reused", 1)
+				} else if stats != nil {
+					config.tracer.count("blamed", 1)
+				} else {
+					config.tracer.count("skipped", 1)
+				}
+				resultsChan <- fileResult{path: file, stats: stats, eol: eol}
+			}
+		}()
 	}
 
 	go func() {
@@ -311,15 +1460,14 @@ func aggregateStats(files chan string, config Config) map[string]ActorStats {
 		close(resultsChan)
 	}()
 
-	for stats := range resultsChan {
-		for actor, info := range stats {
+	for result := range resultsChan {
+		if result.stats != nil {
+			fileStats = append(fileStats, fileStatsOf(result.path, result.eol, result.stats))
+		}
+
+		for actor, info := range result.stats {
 			if existing, ok := finalStats[actor]; ok {
-				existing.Lines += info.Lines
-				existing.Files += info.Files
-				for commit := range info.commitsSet {
-					existing.commitsSet[commit] = struct{}{}
-				}
-				finalStats[actor] = existing
+				finalStats[actor] = mergeActorStats(existing, info)
 			} else {
 				finalStats[actor] = info
 			}
@@ -331,91 +1479,80 @@ func aggregateStats(files chan string, config Config) map[string]ActorStats {
 		finalStats[actor] = stats
 	}
 
-	return finalStats
-}
+	sort.Slice(fileStats, func(i, j int) bool { return fileStats[i].Path < fileStats[j].Path })
 
-func sortByConfig(actors []ActorStats, orderBy string) {
-	sort.Slice(actors, func(i, j int) bool {
-		if actors[i].Commits == actors[j].Commits &&
-			actors[i].Lines == actors[j].Lines &&
-			actors[i].Files == actors[j].Files {
-			return actors[i].Name < actors[j].Name
-		}
+	if err := config.incremental.save(revision); err != nil {
+		fmt.Fprintf(os.Stderr, "Incremental state not saved: %v\n", err)
+	}
 
-		switch orderBy {
-		case "commits":
-			if actors[i].Commits != actors[j].Commits {
-				return actors[i].Commits > actors[j].Commits
-			}
-			if actors[i].Lines != actors[j].Lines {
-				return actors[i].Lines > actors[j].Lines
-			}
+	return finalStats, fileStats
+}
 
-			return actors[i].Files > actors[j].Files
-		case "files":
-			if actors[i].Files != actors[j].Files {
-				return actors[i].Files > actors[j].Files
-			}
-			if actors[i].Lines != actors[j].Lines {
-				return actors[i].Lines > actors[j].Lines
-			}
-			return actors[i].Commits > actors[j].Commits
-		default:
-			if actors[i].Lines != actors[j].Lines {
-				return actors[i].Lines > actors[j].Lines
-			}
-			if actors[i].Commits != actors[j].Commits {
-				return actors[i].Commits > actors[j].Commits
-			}
-			return actors[i].Files > actors[j].Files
+func fileStatsOf(path, eol string, stats map[string]ActorStats) FileStats {
+	file := FileStats{
+		Path:    path,
+		EOL:     eol,
+		Lines:   make(map[string]int, len(stats)),
+		Commits: make(map[string]map[string]struct{}, len(stats)),
+	}
+	for actor, info := range stats {
+		file.Lines[actor] = info.Lines
+		file.Commits[actor] = make(map[string]struct{}, len(info.commitsSet))
+		for commit := range info.commitsSet {
+			file.Commits[actor][commit] = struct{}{}
 		}
-	})
+	}
+	return file
 }
 
-func outputResults(stats map[string]ActorStats, config Config) {
-	actors := make([]ActorStats, 0, len(stats))
-	for _, stat := range stats {
-		actors = append(actors, stat)
+// mergeActorStats adds the per-file or per-repository stats of an actor to
+// the accumulated ones. Commits must be recounted from commitsSet afterwards.
+func mergeActorStats(existing, info ActorStats) ActorStats {
+	existing.Lines += info.Lines
+	existing.Files += info.Files
+	if existing.commitsSet == nil {
+		existing.commitsSet = make(map[string]struct{})
 	}
-
-	sortByConfig(actors, config.OrderBy)
-
-	switch config.Format {
-	case "tabular":
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
-		fmt.Fprintln(w, "Name\tLines\tCommits\tFiles")
-		for _, actor := range actors {
-			fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", actor.Name, actor.Lines, actor.Commits, actor.Files)
-		}
-		w.Flush()
-	case "csv":
-		w := csv.NewWriter(os.Stdout)
-		err := w.Write([]string{"Name", "Lines", "Commits", "Files"})
-		if err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "Writing error: %s", err)
-			os.Exit(1)
-		}
-		for _, actor := range actors {
-			err = w.Write([]string{actor.Name, fmt.Sprint(actor.Lines), fmt.Sprint(actor.Commits), fmt.Sprint(actor.Files)})
-			if err != nil {
-				_, _ = fmt.Fprintf(os.Stderr, "Writing error: %s", err)
-				os.Exit(1)
-			}
-		}
-		w.Flush()
-	case "json":
-		err := json.NewEncoder(os.Stdout).Encode(actors)
-		if err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "Writing error: %s", err)
-			os.Exit(1)
-		}
-	case "json-lines":
-		for _, actor := range actors {
-			err := json.NewEncoder(os.Stdout).Encode(actor)
-			if err != nil {
-				_, _ = fmt.Fprintf(os.Stderr, "Writing error: %s", err)
-				os.Exit(1)
-			}
-		}
+	for commit := range info.commitsSet {
+		existing.commitsSet[commit] = struct{}{}
+	}
+	if existing.authorTimes == nil {
+		existing.authorTimes = make(map[int64]int)
+	}
+	for t, n := range info.authorTimes {
+		existing.authorTimes[t] += n
+	}
+	if existing.roles == nil {
+		existing.roles = make(map[string]int)
+	}
+	for name, n := range info.roles {
+		existing.roles[name] += n
+	}
+	if existing.emails == nil {
+		existing.emails = make(map[string]int)
+	}
+	for email, n := range info.emails {
+		existing.emails[email] += n
+	}
+	if existing.velocity == nil {
+		existing.velocity = make([]int, len(info.velocity))
+	}
+	for i, n := range info.velocity {
+		existing.velocity[i] += n
+	}
+	if existing.trend == nil {
+		existing.trend = make([]int, len(info.trend))
+	}
+	for i, n := range info.trend {
+		existing.trend[i] += n
 	}
+	existing.knowledge += info.knowledge
+	existing.added += info.added
+	existing.code += info.code
+	existing.comments += info.comments
+	existing.blank += info.blank
+	existing.weighted += info.weighted
+	existing.linesLow += info.linesLow
+	existing.linesHigh += info.linesHigh
+	return existing
 }
@@ -0,0 +1,174 @@
+//go:build !solution
+
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// githubRepo is an organization repository as listed by the GitHub API.
+type githubRepo struct {
+	Name     string `json:"name"`
+	CloneURL string `json:"clone_url"`
+	Fork     bool   `json:"fork"`
+	Archived bool   `json:"archived"`
+	Size     int    `json:"size"`
+}
+
+func newOrgCmd(config *Config) *cobra.Command {
+	var token, cacheDir string
+	var includeForks, includeArchived bool
+
+	cmd := &cobra.Command{
+		Use:   "org github.com/<org>",
+		Short: "Reports contributors across all repositories of a GitHub organization",
+		Args:  cobra.ExactArgs(1),
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			org, ok := strings.CutPrefix(strings.TrimPrefix(args[0], "https://"), "github.com/")
+			if !ok || org == "" || strings.Contains(strings.Trim(org, "/"), "/") {
+				fmt.Fprintf(os.Stderr, "Invalid organization: %s\n", args[0])
+				os.Exit(2)
+			}
+			org = strings.Trim(org, "/")
+
+			if token == "" {
+				token = os.Getenv("GITHUB_TOKEN")
+			}
+			if cacheDir == "" {
+				dir, err := os.UserCacheDir()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Invalid cache dir: %v\n", err)
+					os.Exit(2)
+				}
+				cacheDir = filepath.Join(dir, "gitfame", "github.com", org)
+			}
+
+			repos, err := listOrgRepos(newGithubClient(token), org)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid organization: %v\n", err)
+				os.Exit(2)
+			}
+			var selected []githubRepo
+			for _, repo := range repos {
+				// Empty repositories have no revision to analyze.
+				if repo.Size == 0 || repo.Fork && !includeForks || repo.Archived && !includeArchived {
+					continue
+				}
+				selected = append(selected, repo)
+			}
+
+			config.Repositories = syncOrgRepos(selected, cacheDir, token, *config)
+			if len(config.Repositories) == 0 {
+				fmt.Fprintf(os.Stderr, "Invalid organization: no repositories to analyze in %s\n", org)
+				os.Exit(2)
+			}
+			prepareRepositories(config, cmd.Flags())
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			outputResults(collectStats(*config), *config)
+		},
+	}
+
+	cmd.Flags().StringVar(&token, "token", "", "GitHub token (default: $GITHUB_TOKEN)")
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", "", "Directory with the clones, updated on every run (default: user cache dir)")
+	cmd.Flags().BoolVar(&includeForks, "include-forks", false, "Also analyze forked repositories")
+	cmd.Flags().BoolVar(&includeArchived, "include-archived", false, "Also analyze archived repositories")
+
+	return cmd
+}
+
+func listOrgRepos(g githubClient, org string) ([]githubRepo, error) {
+	const perPage = 100
+
+	var repos []githubRepo
+	for page := 1; ; page++ {
+		var batch []githubRepo
+		if err := g.get(fmt.Sprintf("/orgs/%s/repos?type=all&per_page=%d&page=%d", org, perPage, page), &batch); err != nil {
+			return nil, err
+		}
+		repos = append(repos, batch...)
+		if len(batch) < perPage {
+			break
+		}
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+	return repos, nil
+}
+
+// syncOrgRepos clones the repositories into bare mirrors under the cache dir,
+// or fetches the mirrors that already exist, --jobs at a time. It returns the
+// paths of the mirrors that have the revision.
+func syncOrgRepos(repos []githubRepo, cacheDir, token string, config Config) []string {
+	dirs := make([]string, len(repos))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < config.Jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				dir := filepath.Join(cacheDir, repos[i].Name+".git")
+				if err := syncMirror(repos[i].CloneURL, dir, token, config); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %s skipped: %v\n", repos[i].Name, err)
+					continue
+				}
+				dirs[i] = dir
+			}
+		}()
+	}
+	for i := range repos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var synced []string
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		sub := config
+		sub.Repository = dir
+		if !revisionExists(sub, config.Revision) {
+			fmt.Fprintf(os.Stderr, "Warning: %s skipped: no revision %s\n", filepath.Base(dir), config.Revision)
+			continue
+		}
+		synced = append(synced, dir)
+	}
+	return synced
+}
+
+func syncMirror(url, dir, token string, config Config) error {
+	op, args := "clone", []string{"clone", "--quiet", "--bare", "--", url, dir}
+	if _, err := os.Stat(dir); err == nil {
+		op, args = "fetch", []string{"-C", dir, "fetch", "--quiet", "--prune", "--tags", url, "+refs/heads/*:refs/heads/*"}
+	} else if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Env = gitEnv(config.UseUserGitConfig)
+	if token != "" {
+		// The token is passed in the environment to keep it out of the
+		// process list and the stored remote URL.
+		auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		cmd.Env = append(cmd.Env, "GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+auth)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", op, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
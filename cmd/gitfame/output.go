@@ -0,0 +1,362 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// orderKeys lists the keys compared for a single --order-by key before the
+// --tie-break keys. A list of keys is compared as given.
+var orderKeys = map[string][]string{
+	"lines":   {"lines", "commits", "files"},
+	"commits": {"commits", "lines", "files"},
+	"files":   {"files", "lines", "commits"},
+	"name":    {"name"},
+}
+
+func sortByConfig(actors []ActorStats, config Config) {
+	sort.Slice(actors, func(i, j int) bool {
+		return lessActors(actors[i], actors[j], config)
+	})
+}
+
+// lessActors is a total order: the --order-by keys in the --sort direction,
+// numbers descending and names ascending by default, then the --tie-break
+// keys ascending, and the canonical email as the last resort.
+func lessActors(a, b ActorStats, config Config) bool {
+	for _, key := range orderByKeys(config.OrderBy) {
+		c := compareActors(a, b, key)
+		if descending := key != "name"; config.Sort != "" && descending != (config.Sort == "desc") {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+	}
+	for _, key := range append(append([]string{}, config.TieBreak...), "email") {
+		if c := compareActors(a, b, key); c != 0 {
+			return c < 0
+		}
+	}
+	return false
+}
+
+func orderByKeys(orderBy string) []string {
+	if keys, ok := orderKeys[orderBy]; ok {
+		return keys
+	}
+	return strings.Split(orderBy, ",")
+}
+
+func compareActors(a, b ActorStats, key string) int {
+	switch key {
+	case "lines":
+		return cmp.Compare(b.Lines, a.Lines)
+	case "commits":
+		return cmp.Compare(b.Commits, a.Commits)
+	case "files":
+		return cmp.Compare(b.Files, a.Files)
+	case "name":
+		return cmp.Compare(a.Name, b.Name)
+	case "email":
+		return cmp.Compare(canonicalEmail(a), canonicalEmail(b))
+	}
+	return 0
+}
+
+func canonicalEmail(a ActorStats) string {
+	return strings.ToLower(primaryEmail(a))
+}
+
+// table is a format-independent view of a report: Rows are rendered by the
+// tabular, csv and gh-summary formats, Items by json and json-lines.
+type table struct {
+	Headers []string
+	Rows    [][]string
+	Items   []record
+	// Nested marks the rows that detail the actor row above them.
+	Nested []bool
+}
+
+func (t table) nested(row int) bool {
+	return row < len(t.Nested) && t.Nested[row]
+}
+
+// section is an additional table printed after the main one. In json it
+// becomes a top-level array under Name.
+type section struct {
+	Name  string
+	Title string
+	Table table
+}
+
+func actorsTable(actors []ActorStats, columns []column) table {
+	t := table{
+		Headers: columnHeaders(columns),
+		Rows:    make([][]string, 0, len(actors)),
+		Items:   make([]record, 0, len(actors)),
+	}
+	for _, actor := range actors {
+		t.Rows = append(t.Rows, columnValues(columns, actor))
+		t.Items = append(t.Items, columnRecord(columns, actor))
+	}
+	return t
+}
+
+func sortedActors(stats map[string]ActorStats, config Config) []ActorStats {
+	actors := make([]ActorStats, 0, len(stats))
+	total := 0
+	for _, stat := range stats {
+		total += stat.Lines
+	}
+	for _, stat := range stats {
+		stat.share = percent(stat.Lines, total)
+		actors = append(actors, stat)
+	}
+
+	sortByConfig(actors, config)
+	return actors
+}
+
+func reportSections(report Report, config Config) []section {
+	var sections []section
+	if config.NoOwnerThreshold != "" {
+		sections = append(sections, noOwnerSection(report.Files, config))
+	}
+	if config.EOLReport {
+		sections = append(sections, eolSection(report.Files, config))
+	}
+	if config.Concentration {
+		sections = append(sections, concentrationSection(report.Files))
+	}
+	return sections
+}
+
+func outputResults(report Report, config Config) {
+	span := config.tracer.start("format", nil, "format", config.Format)
+	if config.Anonymize {
+		report = anonymizeReport(report, config)
+	}
+	if config.GithubToken != "" || config.GitlabToken != "" {
+		resolveIdentities(report.Actors, config)
+	}
+
+	if len(config.Outputs) > 0 {
+		render := func(config Config) ([]byte, error) {
+			return renderReport(report, config).Bytes(), nil
+		}
+		if err := writeOutputFiles(config, render); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Writing error: %s", err)
+			os.Exit(1)
+		}
+	} else if buf := renderReport(report, config); config.Format == "gh-summary" {
+		writeStepSummary(buf)
+	} else {
+		writeOutput(buf)
+	}
+	span.finish()
+
+	if config.AccessReview != "" {
+		writeAccessReview(report, config)
+	}
+	if config.PartialOutput != "" {
+		writePartial(report, config)
+	}
+	if config.PublishURL != "" {
+		if err := publishReport(report, config); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Publishing error: %s\n", err)
+			removeClones()
+			os.Exit(1)
+		}
+	}
+	if config.NotifySlack != "" {
+		if err := notifySlack(report, config); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Slack notification error: %s\n", err)
+			removeClones()
+			os.Exit(1)
+		}
+	}
+	config.tracer.flush()
+	checkPolicies(report, config)
+}
+
+// renderReport writes the tables of the report in config.Format.
+func renderReport(report Report, config Config) *bytes.Buffer {
+	actors := sortedActors(report.Actors, config)
+	columns := activeColumns(config)
+	sections := reportSections(report, config)
+
+	main := actorsTable(actors, columns)
+	if config.ByFile {
+		main = byFileTable(report.Files, config)
+	} else if config.Breakdown != "" {
+		main = breakdownTable(report.Files, config.Breakdown, config)
+	} else if config.ShowFiles {
+		main = withOwnedFiles(main, actors, report.Files, config.Format == "tabular", config)
+	}
+
+	var buf bytes.Buffer
+	if err := writeSections(&buf, main, sections, report.Metadata, config); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Writing error: %s", err)
+		os.Exit(1)
+	}
+
+	if config.ValidateOutput {
+		if err := validateOutput(buf.Bytes(), actors, columns, config); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Output validation error: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	return &buf
+}
+
+func writeOutput(buf *bytes.Buffer) {
+	if _, err := buf.WriteTo(os.Stdout); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Writing error: %s", err)
+		os.Exit(1)
+	}
+}
+
+// writeCommandOutput writes the output of a subcommand, rendered by render in
+// the format of every --output file, to those files or else to stdout.
+func writeCommandOutput(config Config, render func(out io.Writer, config Config) error) {
+	var err error
+	if len(config.Outputs) > 0 {
+		err = writeOutputFiles(config, func(config Config) ([]byte, error) {
+			var buf bytes.Buffer
+			err := render(&buf, config)
+			return buf.Bytes(), err
+		})
+	} else {
+		var buf bytes.Buffer
+		if err = render(&buf, config); err == nil {
+			_, err = buf.WriteTo(os.Stdout)
+		}
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Writing error: %s", err)
+		os.Exit(1)
+	}
+}
+
+func writeTable(out io.Writer, t table, metadata *Metadata, config Config) error {
+	return writeSections(out, t, nil, metadata, config)
+}
+
+// writeSections renders the tables in config.Format after applying the
+// redaction policy configured for that format.
+func writeSections(out io.Writer, main table, sections []section, metadata *Metadata, config Config) error {
+	format := config.Format
+	if policy, ok := config.File.Redaction[format]; ok {
+		main = redactTable(main, policy)
+		redacted := make([]section, 0, len(sections))
+		for _, s := range sections {
+			s.Table = redactTable(s.Table, policy)
+			redacted = append(redacted, s)
+		}
+		sections = redacted
+	}
+
+	switch format {
+	case "chart":
+		return writeChart(out, main, config)
+	case "influx":
+		return writeInflux(out, main, sections, config)
+	case "gh-summary":
+		fmt.Fprintf(out, "## gitfame\n\n")
+		if metadata != nil {
+			fmt.Fprintf(out, "_%s_\n\n", metadata)
+		}
+		writeMarkdownRows(out, main)
+		for _, s := range sections {
+			fmt.Fprintf(out, "\n### %s\n\n", s.Title)
+			writeMarkdownRows(out, s.Table)
+		}
+		return nil
+	case "tabular", "csv":
+		writeRows := func(t table, colors *tableColors) error {
+			if format == "tabular" {
+				return writeTabular(out, t, colors, config)
+			}
+			return writeCSV(out, t)
+		}
+		writeMetadataComment(out, metadata)
+		if err := writeRows(main, newTableColors(main, config)); err != nil {
+			return err
+		}
+		for _, s := range sections {
+			fmt.Fprintf(out, "\n# %s\n", s.Title)
+			if err := writeRows(s.Table, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		if metadata == nil && len(sections) == 0 {
+			return json.NewEncoder(out).Encode(main.Items)
+		}
+
+		var envelope record
+		if metadata != nil {
+			envelope = append(envelope, field{Key: "metadata", Value: metadata})
+		}
+		envelope = append(envelope, field{Key: "authors", Value: main.Items})
+		for _, s := range sections {
+			envelope = append(envelope, field{Key: s.Name, Value: s.Table.Items})
+		}
+		return json.NewEncoder(out).Encode(envelope)
+	case "json-lines":
+		encoder := json.NewEncoder(out)
+		if metadata != nil {
+			err := encoder.Encode(struct {
+				Metadata *Metadata `json:"metadata"`
+			}{metadata})
+			if err != nil {
+				return err
+			}
+		}
+		for _, item := range main.Items {
+			if err := encoder.Encode(item); err != nil {
+				return err
+			}
+		}
+		for _, s := range sections {
+			for _, item := range s.Table.Items {
+				if err := encoder.Encode(append(record{{Key: "section", Value: s.Name}}, item...)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func writeCSV(out io.Writer, t table) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(t.Headers); err != nil {
+		return err
+	}
+	for _, row := range t.Rows {
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeMetadataComment(out io.Writer, metadata *Metadata) {
+	if metadata == nil {
+		return
+	}
+	fmt.Fprintf(out, "# %s\n", metadata)
+}
@@ -0,0 +1,143 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// compressionSuffixes are appended to the names of files written with
+// --compress.
+var compressionSuffixes = map[string]string{
+	"gzip": ".gz",
+	"zstd": ".zst",
+}
+
+// outputFormats maps the extensions of --output files to formats; other
+// files are written in --format.
+var outputFormats = map[string]string{
+	".txt":   "tabular",
+	".csv":   "csv",
+	".json":  "json",
+	".jsonl": "json-lines",
+	".md":    "gh-summary",
+	".lp":    "influx",
+}
+
+func outputFormat(path string, config Config) string {
+	for _, suffix := range compressionSuffixes {
+		path = strings.TrimSuffix(path, suffix)
+	}
+	if format, ok := outputFormats[strings.ToLower(filepath.Ext(path))]; ok {
+		return format
+	}
+	return config.Format
+}
+
+// writeOutputFiles renders the output once per --output file, in the format
+// picked by its extension.
+func writeOutputFiles(config Config, render func(config Config) ([]byte, error)) error {
+	for _, path := range config.Outputs {
+		sub := config
+		sub.Format = outputFormat(path, config)
+		data, err := render(sub)
+		if err != nil {
+			return err
+		}
+		if err := writeReportFile(path, data, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeReportFile compresses the data with --compress, adding the suffix of
+// the compression to the path unless it is already there, and writes it
+// atomically.
+func writeReportFile(path string, data []byte, config Config) error {
+	if config.Compress == "" {
+		return writeFileAtomic(path, data)
+	}
+	if suffix := compressionSuffixes[config.Compress]; !strings.HasSuffix(path, suffix) {
+		path += suffix
+	}
+
+	var buf bytes.Buffer
+	switch config.Compress {
+	case "gzip":
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+	case "zstd":
+		cmd := exec.Command("zstd", "--quiet", "--stdout")
+		cmd.Stdin = bytes.NewReader(data)
+		cmd.Stdout = &buf
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("zstd: %w", err)
+		}
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// readReportFile reads a file written by writeReportFile, decompressing it
+// by its magic number.
+func readReportFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	case bytes.HasPrefix(data, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		cmd := exec.Command("zstd", "--quiet", "--decompress", "--stdout")
+		cmd.Stdin = bytes.NewReader(data)
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("zstd: %w", err)
+		}
+		return out, nil
+	}
+	return data, nil
+}
+
+// writeFileAtomic writes the file under a temporary name in the same
+// directory and renames it into place, so that readers never see a
+// truncated file.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	_, err = bytes.NewReader(data).WriteTo(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o644)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,105 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// directoryLines sums per-actor lines of every directory, including the
+// lines of all nested directories. The repository root is ".".
+func directoryLines(files []FileStats) map[string]map[string]int {
+	dirs := make(map[string]map[string]int)
+	for _, file := range files {
+		for dir := path.Dir(file.Path); ; dir = path.Dir(dir) {
+			if dirs[dir] == nil {
+				dirs[dir] = make(map[string]int)
+			}
+			for actor, n := range file.Lines {
+				dirs[dir][actor] += n
+			}
+			if dir == "." {
+				break
+			}
+		}
+	}
+	return dirs
+}
+
+// topOwner returns the actor with the most lines, the total number of lines
+// and the owner's share in percent.
+func topOwner(lines map[string]int) (string, int, float64) {
+	owner, ownerLines, total := "", 0, 0
+	for actor, n := range lines {
+		total += n
+		if n > ownerLines || (n == ownerLines && actor < owner) {
+			owner, ownerLines = actor, n
+		}
+	}
+	if total == 0 {
+		return owner, 0, 0
+	}
+	return owner, total, percent(ownerLines, total)
+}
+
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*1000) / 10
+}
+
+// parsePercent parses thresholds like "25%" or "25".
+func parsePercent(s string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil || value < 0 || value > 100 {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	return value, nil
+}
+
+func noOwnerSection(files []FileStats, config Config) section {
+	threshold, _ := parsePercent(config.NoOwnerThreshold)
+
+	type row struct {
+		dir   string
+		owner string
+		share float64
+		total int
+	}
+
+	var rows []row
+	for dir, lines := range directoryLines(files) {
+		owner, total, share := topOwner(lines)
+		if total > 0 && share < threshold {
+			rows = append(rows, row{dir: dir, owner: owner, share: share, total: total})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].dir < rows[j].dir })
+
+	t := table{
+		Headers: []string{"Directory", "TopOwner", "Share%", "Lines"},
+		Rows:    make([][]string, 0, len(rows)),
+		Items:   make([]record, 0, len(rows)),
+	}
+	for _, r := range rows {
+		t.Rows = append(t.Rows, []string{r.dir, r.owner, formatValue(r.share), strconv.Itoa(r.total)})
+		t.Items = append(t.Items, record{
+			{Key: "directory", Value: r.dir},
+			{Key: "top_owner", Value: r.owner},
+			{Key: "share", Value: r.share},
+			{Key: "lines", Value: r.total},
+		})
+	}
+
+	return section{
+		Name:  "no_owner",
+		Title: fmt.Sprintf("Directories without an owner reaching %s%%", formatValue(threshold)),
+		Table: t,
+	}
+}
@@ -0,0 +1,177 @@
+//go:build !solution
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// partialResult is the file written by --partial-output. It keeps the commit
+// ids behind every count, so that shards of one analysis can be merged
+// without counting a commit or a file twice.
+type partialResult struct {
+	// Revisions maps repository names to the analyzed commit.
+	Revisions map[string]string `json:"revisions"`
+	Metadata  *Metadata         `json:"metadata,omitempty"`
+	Files     []partialFile     `json:"files"`
+	// Emails counts the lines of every actor by email.
+	Emails map[string]map[string]int `json:"emails"`
+}
+
+type partialFile struct {
+	Path    string              `json:"path"`
+	Lines   map[string]int      `json:"lines"`
+	Commits map[string][]string `json:"commits"`
+}
+
+func newMergeCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "merge <partial.json>...",
+		Short: "Combines results written with --partial-output into one report",
+		Args:  cobra.MinimumNArgs(1),
+		// The partial results are all that is needed, not a repository.
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		Run: func(cmd *cobra.Command, args []string) {
+			report, err := mergePartials(args)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid partial result: %v\n", err)
+				os.Exit(2)
+			}
+			outputResults(report, *config)
+		},
+	}
+	return cmd
+}
+
+func writePartial(report Report, config Config) {
+	partial := partialResult{
+		Revisions: make(map[string]string, len(config.repositories)),
+		Metadata:  report.Metadata,
+		Files:     make([]partialFile, 0, len(report.Files)),
+		Emails:    make(map[string]map[string]int, len(report.Actors)),
+	}
+	for name, rev := range report.revisions {
+		partial.Revisions[name] = rev
+	}
+	for _, r := range config.repositories {
+		sub := config
+		sub.Repository = r.Path
+		partial.Revisions[r.Name] = revisionHash(sub, config.Revision)
+	}
+	for _, file := range report.Files {
+		pf := partialFile{Path: file.Path, Lines: file.Lines, Commits: make(map[string][]string, len(file.Commits))}
+		for actor, commits := range file.Commits {
+			pf.Commits[actor] = sortedKeys(commits)
+		}
+		partial.Files = append(partial.Files, pf)
+	}
+	for actor, s := range report.Actors {
+		partial.Emails[actor] = s.emails
+	}
+
+	data, err := json.Marshal(partial)
+	if err == nil {
+		err = writeReportFile(config.PartialOutput, append(data, '\n'), config)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Writing error: %s", err)
+		os.Exit(1)
+	}
+}
+
+// mergePartials unions the files of the partial results; a file present in
+// several of them is counted once. Actor stats are recomputed from the files.
+func mergePartials(paths []string) (Report, error) {
+	revisions := make(map[string]string)
+	files := make(map[string]FileStats)
+	emails := make(map[string]map[string]int)
+	var metadata *Metadata
+
+	for _, path := range paths {
+		data, err := readReportFile(path)
+		if err != nil {
+			return Report{}, err
+		}
+		var partial partialResult
+		if err := json.Unmarshal(data, &partial); err != nil {
+			return Report{}, fmt.Errorf("%s: %w", path, err)
+		}
+
+		for name, rev := range partial.Revisions {
+			if seen, ok := revisions[name]; ok && seen != rev {
+				return Report{}, fmt.Errorf("%s: %s is at %s, other results are at %s", path, name, rev, seen)
+			}
+			revisions[name] = rev
+		}
+		if partial.Metadata != nil && (metadata == nil || partial.Metadata.Approximate) {
+			metadata = partial.Metadata
+		}
+
+		for _, pf := range partial.Files {
+			if _, ok := files[pf.Path]; ok {
+				continue
+			}
+			file := FileStats{Path: pf.Path, Lines: pf.Lines, Commits: make(map[string]map[string]struct{}, len(pf.Commits))}
+			for actor, commits := range pf.Commits {
+				file.Commits[actor] = make(map[string]struct{}, len(commits))
+				for _, commit := range commits {
+					file.Commits[actor][commit] = struct{}{}
+				}
+			}
+			files[pf.Path] = file
+		}
+		for actor, counts := range partial.Emails {
+			if emails[actor] == nil {
+				emails[actor] = make(map[string]int)
+			}
+			for email, n := range counts {
+				emails[actor][email] += n
+			}
+		}
+	}
+
+	report := Report{Actors: make(map[string]ActorStats), Metadata: metadata, revisions: revisions}
+	for _, file := range files {
+		report.Files = append(report.Files, file)
+		for actor, n := range file.Lines {
+			s, ok := report.Actors[actor]
+			if !ok {
+				s = ActorStats{Name: actor, commitsSet: make(map[string]struct{}), emails: emails[actor]}
+			}
+			s.Lines += n
+			s.Files++
+			for commit := range file.Commits[actor] {
+				s.commitsSet[commit] = struct{}{}
+			}
+			report.Actors[actor] = s
+		}
+	}
+	for actor, s := range report.Actors {
+		s.Commits = len(s.commitsSet)
+		report.Actors[actor] = s
+	}
+	sort.Slice(report.Files, func(i, j int) bool { return report.Files[i].Path < report.Files[j].Path })
+	return report, nil
+}
+
+func revisionHash(config Config, revision string) string {
+	commit, ok := resolveRevision(config, revision)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git rev-parse: %s\n", revision)
+	}
+	return commit
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,47 @@
+//go:build !solution
+
+package main
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// formatPath renders a repository path (as listed by ls-tree) according to
+// --path-style. Every output that lists files of the revision goes through
+// it; directory aggregates keep repository-relative names. Files of remote
+// repositories keep them too, their clones do not outlive the run.
+func formatPath(file string, config Config) string {
+	if config.PathStyle == "relative" {
+		return file
+	}
+
+	root, rel := config.Repository, file
+	if len(config.repositories) > 1 {
+		// Files of several repositories are prefixed with the name of theirs.
+		name, rest, _ := strings.Cut(file, "/")
+		for _, r := range config.repositories {
+			if r.Name == name {
+				if r.Remote {
+					return file
+				}
+				root, rel = r.Path, rest
+			}
+		}
+	} else if len(config.repositories) == 1 && config.repositories[0].Remote {
+		return file
+	}
+
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return file
+	}
+	absolute := filepath.Join(root, filepath.FromSlash(rel))
+
+	if config.PathStyle == "uri" {
+		u := url.URL{Scheme: "file", Path: filepath.ToSlash(absolute)}
+		return u.String()
+	}
+	return absolute
+}
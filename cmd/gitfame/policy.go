@@ -0,0 +1,87 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Exit codes of violated policies; 1 and 2 are runtime and usage errors.
+const (
+	exitTopOwnerAbove  = 3
+	exitBusFactorBelow = 4
+	// exitCodeownersStale is returned by codeowners check.
+	exitCodeownersStale = 5
+)
+
+// busFactor returns the smallest number of actors owning more than half of
+// the lines.
+func busFactor(stats map[string]ActorStats) int {
+	lines := make([]int, 0, len(stats))
+	total := 0
+	for _, s := range stats {
+		lines = append(lines, s.Lines)
+		total += s.Lines
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(lines)))
+
+	owned := 0
+	for i, n := range lines {
+		owned += n
+		if 2*owned > total {
+			return i + 1
+		}
+	}
+	return len(lines)
+}
+
+// topOwnerShare returns the name and the share of lines of the actor owning
+// the most lines.
+func topOwnerShare(stats map[string]ActorStats) (string, float64) {
+	counts := make(map[string]int, len(stats))
+	total := 0
+	for name, s := range stats {
+		counts[name] = s.Lines
+		total += s.Lines
+	}
+	top := topNames(counts, 1)
+	if len(top) == 0 {
+		return "", 0
+	}
+	return top[0], percent(counts[top[0]], total)
+}
+
+// checkPolicies reports every violated --fail-if-* policy to stderr and exits
+// with the code of the first one.
+func checkPolicies(report Report, config Config) {
+	code := 0
+	if config.FailIfTopOwnerAbove != "" {
+		limit, _ := parsePercent(config.FailIfTopOwnerAbove)
+		if owner, share := topOwnerShare(report.Actors); share > limit {
+			message := fmt.Sprintf("%s owns %v%% of the lines, above %v%%", owner, share, limit)
+			fmt.Fprintf(os.Stderr, "Policy violated: %s\n", message)
+			if config.Format == "gh-summary" {
+				annotate("Top owner policy", message)
+			}
+			code = exitTopOwnerAbove
+		}
+	}
+	if config.FailIfBusFactorBelow > 0 {
+		if n := busFactor(report.Actors); n < config.FailIfBusFactorBelow {
+			message := fmt.Sprintf("bus factor is %d, below %d", n, config.FailIfBusFactorBelow)
+			fmt.Fprintf(os.Stderr, "Policy violated: %s\n", message)
+			if config.Format == "gh-summary" {
+				annotate("Bus factor policy", message)
+			}
+			if code == 0 {
+				code = exitBusFactorBelow
+			}
+		}
+	}
+	if code != 0 {
+		removeClones()
+		os.Exit(code)
+	}
+}
@@ -0,0 +1,94 @@
+//go:build !solution
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"gogitfame/configs"
+)
+
+// redactionSinks are the outputs a redaction policy can be configured for.
+var redactionSinks = map[string]bool{
+	"tabular":       true,
+	"csv":           true,
+	"json":          true,
+	"json-lines":    true,
+	"gh-summary":    true,
+	"influx":        true,
+	"chart":         true,
+	"access-review": true,
+}
+
+// redactTable drops and masks columns by their record keys. Tables without
+// items have no keys to match and are returned as is.
+func redactTable(t table, policy configs.RedactionPolicy) table {
+	if len(t.Items) == 0 || len(t.Items) != len(t.Rows) || len(t.Items[0]) != len(t.Headers) {
+		return t
+	}
+
+	drop := make(map[string]bool, len(policy.Drop))
+	for _, key := range policy.Drop {
+		drop[key] = true
+	}
+	mask := make(map[string]bool, len(policy.Mask))
+	for _, key := range policy.Mask {
+		mask[key] = true
+	}
+
+	redacted := table{
+		Rows:  make([][]string, 0, len(t.Rows)),
+		Items: make([]record, 0, len(t.Items)),
+	}
+	for i, f := range t.Items[0] {
+		if !drop[f.Key] {
+			redacted.Headers = append(redacted.Headers, t.Headers[i])
+		}
+	}
+
+	for i, item := range t.Items {
+		var row []string
+		var rec record
+		for j, f := range item {
+			switch {
+			case drop[f.Key]:
+				continue
+			case mask[f.Key]:
+				f.Value = maskValue(f.Value)
+				row = append(row, formatValue(f.Value))
+			default:
+				row = append(row, t.Rows[i][j])
+			}
+			rec = append(rec, f)
+		}
+		redacted.Rows = append(redacted.Rows, row)
+		redacted.Items = append(redacted.Items, rec)
+	}
+	return redacted
+}
+
+// maskValue replaces strings with a stable hash, so that masked values can
+// still be joined across reports.
+func maskValue(v any) any {
+	switch v := v.(type) {
+	case string:
+		return maskString(v)
+	case []string:
+		masked := make([]string, 0, len(v))
+		for _, s := range v {
+			masked = append(masked, maskString(s))
+		}
+		return masked
+	default:
+		return v
+	}
+}
+
+func maskString(s string) string {
+	if s == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(s))
+	return "redacted-" + hex.EncodeToString(sum[:4])
+}
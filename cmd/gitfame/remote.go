@@ -0,0 +1,61 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+)
+
+// remoteRepositoryRegexp matches URLs like https://host/repo.git and
+// scp-like addresses like git@host:org/repo.git.
+var remoteRepositoryRegexp = regexp.MustCompile(`^([a-z][a-z0-9+.-]*://|[^/@:]+@[^/:]+:)`)
+
+// clonedRepositories are the temporary clones of remote repositories.
+var clonedRepositories []string
+
+func isRemoteRepository(repository string) bool {
+	return remoteRepositoryRegexp.MatchString(repository)
+}
+
+// cloneRepository clones a remote repository into a temporary directory and
+// returns its path. The clone is bare unless submodules are analyzed, which
+// needs them checked out. Unlike the analysis, it runs with the environment
+// and configs of the user, which hold the credentials.
+func cloneRepository(url string, config Config) (string, error) {
+	dir, err := os.MkdirTemp("", "gitfame-")
+	if err != nil {
+		return "", err
+	}
+	clonedRepositories = append(clonedRepositories, dir)
+
+	args := []string{"clone", "--quiet"}
+	if config.RecurseSubmodules {
+		args = append(args, "--recurse-submodules")
+	} else {
+		args = append(args, "--bare")
+	}
+	if config.CloneDepth > 0 {
+		args = append(args, "--depth", strconv.Itoa(config.CloneDepth), "--no-single-branch")
+	}
+
+	cmd := exec.Command("git", append(args, "--", url, dir)...)
+	cmd.Env = cloneEnv()
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git clone %s: %w", url, err)
+	}
+	return dir, nil
+}
+
+func removeClones() {
+	// The cat-file processes must be gone before their repositories are.
+	stopCatFiles()
+	for _, dir := range clonedRepositories {
+		_ = os.RemoveAll(dir)
+	}
+	clonedRepositories = nil
+}
@@ -0,0 +1,120 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// repository is an analyzed repository: Path is where it is on disk and Name
+// prefixes its files when several repositories are merged.
+type repository struct {
+	Name string
+	Path string
+	// Remote repositories are cloned to a temporary directory removed on
+	// exit.
+	Remote bool
+}
+
+// resolveRepositories clones the remote repositories and gives every
+// repository a unique name derived from its path or URL.
+func resolveRepositories(config Config) ([]repository, error) {
+	repositories := make([]repository, 0, len(config.Repositories))
+	seen := make(map[string]int)
+	for _, spec := range config.Repositories {
+		r := repository{Name: repositoryName(spec), Path: spec}
+		if isRemoteRepository(spec) {
+			dir, err := cloneRepository(spec, config)
+			if err != nil {
+				return nil, err
+			}
+			r.Path, r.Remote = dir, true
+		}
+
+		seen[r.Name]++
+		if n := seen[r.Name]; n > 1 {
+			r.Name = fmt.Sprintf("%s-%d", r.Name, n)
+		}
+		repositories = append(repositories, r)
+	}
+	return repositories, nil
+}
+
+// repositoryName returns the last path element of a repository path or URL
+// without the .git suffix.
+func repositoryName(spec string) string {
+	if !isRemoteRepository(spec) {
+		if abs, err := filepath.Abs(spec); err == nil {
+			spec = abs
+		}
+	}
+
+	spec = strings.TrimSuffix(strings.TrimRight(filepath.ToSlash(spec), "/"), ".git")
+	if i := strings.LastIndexAny(spec, "/:"); i >= 0 {
+		spec = spec[i+1:]
+	}
+	return spec
+}
+
+// repositoryOf returns the name of the repository a reported file comes from.
+func repositoryOf(file string, config Config) string {
+	if len(config.repositories) == 1 {
+		return config.repositories[0].Name
+	}
+	name, _, _ := strings.Cut(file, "/")
+	return name
+}
+
+// collectRepositories analyzes every repository at the revision and merges
+// the actors; file paths are prefixed with the repository name.
+func collectRepositories(config Config) Report {
+	stats := make(map[string]ActorStats)
+	var files, unprefixed []FileStats
+	var metadata *Metadata
+	var revisionTime int64
+
+	for _, r := range config.repositories {
+		sub := config
+		sub.Repository = r.Path
+		sub.repositories = []repository{r}
+		// Age, depth and areas are computed once over the merged stats.
+		sub.Age, sub.DepthProfile, sub.TopAreas = false, false, 0
+
+		report := collectStats(sub)
+		for actor, info := range report.Actors {
+			if existing, ok := stats[actor]; ok {
+				stats[actor] = mergeActorStats(existing, info)
+			} else {
+				stats[actor] = info
+			}
+		}
+		for _, file := range report.Files {
+			unprefixed = append(unprefixed, file)
+			file.Path = path.Join(r.Name, file.Path)
+			files = append(files, file)
+		}
+		metadata = report.Metadata
+		revisionTime = max(revisionTime, commitTime(sub, sub.Revision))
+	}
+
+	for actor, s := range stats {
+		s.Commits = len(s.commitsSet)
+		if config.Age {
+			s.avgAge, s.medianAge = lineAges(s.authorTimes, revisionTime)
+		}
+		stats[actor] = s
+	}
+	if metadata != nil {
+		m := *metadata
+		m.Revision = ""
+		metadata = &m
+	}
+	computeDepth(stats, unprefixed, config)
+	computeAreas(stats, files, config)
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return Report{Actors: stats, Files: files, Metadata: metadata}
+}
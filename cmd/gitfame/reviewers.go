@@ -0,0 +1,255 @@
+//go:build !solution
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// touchedFile lists the line ranges of a file that a diff changes, in the
+// numbering of the file before the change.
+type touchedFile struct {
+	File   string
+	Ranges [][2]int
+}
+
+type reviewerRow struct {
+	Name  string
+	Lines int
+	Files int
+}
+
+func newSuggestReviewersCmd(config *Config) *cobra.Command {
+	var diffFile, base string
+	var context, top int
+	var ignore []string
+
+	cmd := &cobra.Command{
+		Use:   "suggest-reviewers",
+		Short: "Ranks the authors of the code around the lines a diff touches",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if (diffFile == "") == (base == "") {
+				fmt.Fprintf(os.Stderr, "Exactly one of --diff and --base is required\n")
+				os.Exit(2)
+			}
+			if context < 0 || top <= 0 {
+				fmt.Fprintf(os.Stderr, "Invalid context or top: %d, %d\n", context, top)
+				os.Exit(2)
+			}
+
+			// A patch file applies on top of the revision, a base branch is
+			// compared from its merge base.
+			var patch io.Reader
+			old := config.Revision
+			if diffFile != "" {
+				data, err := os.ReadFile(diffFile)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Invalid diff: %v\n", err)
+					os.Exit(2)
+				}
+				patch = bytes.NewReader(data)
+			} else {
+				if !revisionExists(*config, base) {
+					fmt.Fprintf(os.Stderr, "Invalid revision: %s\n", base)
+					os.Exit(2)
+				}
+				out, err := gitCommand(*config, "merge-base", base, config.Revision).Output()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git merge-base: %v\n", err)
+					os.Exit(1)
+				}
+				old = strings.TrimSpace(string(out))
+				out, err = gitCommand(*config, "diff", "--unified=0", "--no-renames", old, config.Revision).Output()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git diff: %v\n", err)
+					os.Exit(1)
+				}
+				patch = bytes.NewReader(out)
+			}
+
+			touched := touchedFiles(patch, *config)
+			rows := reviewerStats(touched, old, context, *config)
+
+			ignored := make(map[string]bool, len(ignore))
+			for _, id := range ignore {
+				ignored[id] = true
+			}
+			kept := rows[:0]
+			for _, r := range rows {
+				if !ignored[r.Name] {
+					kept = append(kept, r)
+				}
+			}
+			total := 0
+			for _, r := range kept {
+				total += r.Lines
+			}
+			if len(kept) > top {
+				kept = kept[:top]
+			}
+
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				return writeTable(out, reviewersTable(kept, total, config), nil, config)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&diffFile, "diff", "", "Unified diff applying on top of --revision")
+	cmd.Flags().StringVar(&base, "base", "", "Compare --revision with its merge base with this branch")
+	cmd.Flags().IntVar(&context, "context", 3, "Lines around every change that count as surrounding code")
+	cmd.Flags().IntVar(&top, "top", 5, "Number of suggested reviewers")
+	cmd.Flags().StringSliceVar(&ignore, "ignore-author", nil, "Authors never suggested, like the author of the change")
+
+	return cmd
+}
+
+// touchedFiles parses a unified diff. Pure insertions touch the line they
+// follow; new files have no history to blame and are skipped. File headers
+// end at the first hunk, after which "--- " lines are deleted content.
+func touchedFiles(patch io.Reader, config Config) []touchedFile {
+	var result []touchedFile
+	var current *touchedFile
+	// A patch of one file may start right at its header.
+	inHeader := true
+
+	flush := func() {
+		if current != nil && len(current.Ranges) > 0 && matchesFilters(current.File, config) {
+			result = append(result, *current)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(patch)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			inHeader = true
+		case inHeader && strings.HasPrefix(line, "--- "):
+			flush()
+			if path, ok := strings.CutPrefix(diffHeaderPath(line[len("--- "):]), "a/"); ok {
+				current = &touchedFile{File: path}
+			}
+		case strings.HasPrefix(line, "@@ -"):
+			inHeader = false
+			if start, count, ok := parseOldRange(line); ok && current != nil {
+				current.Ranges = append(current.Ranges, [2]int{start, max(count, 1)})
+			}
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка чтения diff: %v\n", err)
+		return nil
+	}
+	return result
+}
+
+// reviewerStats blames the touched ranges, widened by context lines, at the
+// old revision and ranks their actors by lines.
+func reviewerStats(files []touchedFile, old string, context int, config Config) []reviewerRow {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	lines := make(map[string]int)
+	fileCounts := make(map[string]int)
+	sem := make(chan struct{}, config.Jobs)
+
+	for _, f := range files {
+		wg.Add(1)
+		go func(f touchedFile) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			content, err := gitCommand(config, "show", old+":"+f.File).Output()
+			if err != nil {
+				return
+			}
+			length := bytes.Count(content, []byte("\n"))
+			if len(content) > 0 && content[len(content)-1] != '\n' {
+				length++
+			}
+			if length == 0 {
+				return
+			}
+
+			args := []string{"blame", "--line-porcelain"}
+			for _, r := range f.Ranges {
+				start := min(max(r[0]-context, 1), length)
+				end := min(r[0]+r[1]-1+context, length)
+				args = append(args, "-L", fmt.Sprintf("%d,%d", start, max(start, end)))
+			}
+			args = append(args, old, "--", f.File)
+
+			var out bytes.Buffer
+			cmd := gitCommand(config, args...)
+			cmd.Stdout = &out
+			if err := cmd.Run(); err != nil {
+				return
+			}
+
+			owned := make(map[string]int)
+			forEachBlameGroup(out.String(), config, func(g blameGroup) {
+				owned[g.Actor] += g.Lines
+			})
+
+			mu.Lock()
+			for actor, n := range owned {
+				lines[actor] += n
+				fileCounts[actor]++
+			}
+			mu.Unlock()
+		}(f)
+	}
+	wg.Wait()
+
+	rows := make([]reviewerRow, 0, len(lines))
+	for actor, n := range lines {
+		rows = append(rows, reviewerRow{Name: actor, Lines: n, Files: fileCounts[actor]})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Lines != rows[j].Lines {
+			return rows[i].Lines > rows[j].Lines
+		}
+		if rows[i].Files != rows[j].Files {
+			return rows[i].Files > rows[j].Files
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+// reviewersTable lists the suggested reviewers with their share of all the
+// blamed lines.
+func reviewersTable(rows []reviewerRow, total int, config Config) table {
+	t := table{
+		Headers: []string{"Name", "Lines", "Files", "Share%"},
+		Rows:    make([][]string, 0, len(rows)),
+		Items:   make([]record, 0, len(rows)),
+	}
+	for _, r := range rows {
+		share, name := percent(r.Lines, total), anonymizedName(r.Name, config)
+		t.Rows = append(t.Rows, []string{name, strconv.Itoa(r.Lines), strconv.Itoa(r.Files), formatValue(share)})
+		t.Items = append(t.Items, record{
+			{Key: "name", Value: name},
+			{Key: "lines", Value: r.Lines},
+			{Key: "files", Value: r.Files},
+			{Key: "share", Value: share},
+		})
+	}
+	return t
+}
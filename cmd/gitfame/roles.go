@@ -0,0 +1,36 @@
+//go:build !solution
+
+package main
+
+import "sort"
+
+const topRoles = 3
+
+func rolesColumn(config Config) column {
+	c := column{Header: "Committers", Key: "committers"}
+	if config.UseCommitter {
+		c = column{Header: "Authors", Key: "authors"}
+	}
+	c.Value = func(a ActorStats) any { return topNames(a.roles, topRoles) }
+	return c
+}
+
+// topNames returns up to n names with the most lines.
+func topNames(lines map[string]int, n int) []string {
+	names := make([]string, 0, len(lines))
+	for name := range lines {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if lines[names[i]] != lines[names[j]] {
+			return lines[names[i]] > lines[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	if len(names) > n {
+		names = names[:n]
+	}
+	return names
+}
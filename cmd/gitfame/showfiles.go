@@ -0,0 +1,77 @@
+//go:build !solution
+
+package main
+
+import (
+	"sort"
+	"strconv"
+)
+
+// ownedFile is a file an actor owns lines in, for --show-files.
+type ownedFile struct {
+	Path  string
+	Lines int
+}
+
+// ownedFiles lists the files of every actor, the most lines first.
+func ownedFiles(files []FileStats) map[string][]ownedFile {
+	owned := make(map[string][]ownedFile)
+	for _, file := range files {
+		for actor, n := range file.Lines {
+			if n > 0 {
+				owned[actor] = append(owned[actor], ownedFile{Path: file.Path, Lines: n})
+			}
+		}
+	}
+	for _, list := range owned {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Lines != list[j].Lines {
+				return list[i].Lines > list[j].Lines
+			}
+			return list[i].Path < list[j].Path
+		})
+	}
+	return owned
+}
+
+// withOwnedFiles adds the files of every actor of the table to its item
+// under owned_files and, when indent is set, as indented rows under its row
+// with the path in the Name column and the count in the Lines column.
+func withOwnedFiles(t table, actors []ActorStats, files []FileStats, indent bool, config Config) table {
+	name, lines := 0, -1
+	for j, header := range t.Headers {
+		switch header {
+		case "Name":
+			name = j
+		case "Lines":
+			lines = j
+		}
+	}
+
+	owned := ownedFiles(files)
+	result := table{Headers: t.Headers, Items: make([]record, 0, len(t.Items))}
+	for i, actor := range actors {
+		list := make([]record, 0, len(owned[actor.Name]))
+		for _, f := range owned[actor.Name] {
+			list = append(list, record{{Key: "path", Value: formatPath(f.Path, config)}, {Key: "lines", Value: f.Lines}})
+		}
+		result.Items = append(result.Items, append(t.Items[i], field{Key: "owned_files", Value: list}))
+
+		result.Rows = append(result.Rows, t.Rows[i])
+		result.Nested = append(result.Nested, false)
+		if !indent {
+			continue
+		}
+		for _, f := range owned[actor.Name] {
+			// The row ends with its last cell, so it is not padded.
+			row := make([]string, max(name, lines)+1)
+			row[name] = "  " + formatPath(f.Path, config)
+			if lines >= 0 {
+				row[lines] = strconv.Itoa(f.Lines)
+			}
+			result.Rows = append(result.Rows, row)
+			result.Nested = append(result.Nested, true)
+		}
+	}
+	return result
+}
@@ -0,0 +1,85 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// skippedFilesShown caps how many skipped files are named in the warning.
+const skippedFilesShown = 10
+
+var sizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses sizes like 1MB, 500KB or 1048576.
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, multiplier = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// analyzedFiles lists the files of the revision that are passed to the
+// filters, reporting problems with the selection to stderr.
+func analyzedFiles(config Config) []string {
+	files := getFiles(config)
+	warnUnmatchedExtensions(files, config)
+	if config.filesFrom != nil && len(files) < len(config.filesFrom) {
+		fmt.Fprintf(os.Stderr, "Warning: %d paths from --files-from are not files of %s\n", len(config.filesFrom)-len(files), config.revisionName)
+	}
+	return skipLargeFiles(files, config)
+}
+
+func skipLargeFiles(files []string, config Config) []string {
+	if config.maxFileSize == 0 {
+		return files
+	}
+
+	sizes := fileSizes(config)
+	kept := make([]string, 0, len(files))
+	var skipped []string
+	for _, file := range files {
+		if sizes[file] > config.maxFileSize {
+			skipped = append(skipped, file)
+			continue
+		}
+		kept = append(kept, file)
+	}
+
+	config.tracer.count("scanned", len(skipped))
+	config.tracer.count("skipped", len(skipped))
+	if len(skipped) > 0 {
+		shown := skipped
+		if len(shown) > skippedFilesShown {
+			shown = shown[:skippedFilesShown]
+		}
+		more := ""
+		if len(skipped) > len(shown) {
+			more = fmt.Sprintf(" and %d more", len(skipped)-len(shown))
+		}
+		fmt.Fprintf(os.Stderr, "Warning: skipped %d files larger than %s: %s%s\n",
+			len(skipped), config.MaxFileSize, strings.Join(shown, ", "), more)
+	}
+	return kept
+}
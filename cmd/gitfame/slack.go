@@ -0,0 +1,76 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// slackTop is the number of contributors listed in a Slack notification.
+const slackTop = 5
+
+// slackMessage formats the summary posted by --notify-slack in Slack mrkdwn:
+// the top contributors and the bus factor, with their changes since the
+// --slack-baseline revision when it is set.
+func slackMessage(report Report, config Config) string {
+	var baseline map[string]ActorStats
+	if config.SlackBaseline != "" {
+		sub := config
+		sub.Revision = config.SlackBaseline
+		baseline = collectStats(sub).Actors
+	}
+
+	name := repositoryName(config.Repository)
+	if len(config.repositories) == 1 {
+		name = config.repositories[0].Name
+	} else if len(config.repositories) > 1 {
+		name = fmt.Sprintf("%d repositories", len(config.repositories))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*gitfame* %s at `%s`\n", name, config.revisionName)
+
+	total := 0
+	for _, s := range report.Actors {
+		total += s.Lines
+	}
+	actors := sortedActors(report.Actors, config)
+	for i, a := range actors[:min(len(actors), slackTop)] {
+		fmt.Fprintf(&b, "%d. %s: %d lines (%v%%), %d commits, %d files", i+1, a.Name, a.Lines, percent(a.Lines, total), a.Commits, a.Files)
+		if baseline != nil {
+			fmt.Fprintf(&b, ", %s lines since `%s`", signed(a.Lines-baseline[a.Name].Lines), config.SlackBaseline)
+		}
+		b.WriteByte('\n')
+	}
+
+	fmt.Fprintf(&b, "Bus factor: %d", busFactor(report.Actors))
+	if baseline != nil {
+		fmt.Fprintf(&b, " (%d at `%s`)", busFactor(baseline), config.SlackBaseline)
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+func notifySlack(report Report, config Config) error {
+	data, err := json.Marshal(map[string]string{"text": slackMessage(report, config)})
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(config.NotifySlack, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("POST webhook: %s", resp.Status)
+	}
+	return nil
+}
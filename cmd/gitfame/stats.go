@@ -0,0 +1,67 @@
+//go:build !solution
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// statsStages are the stages listed by --stats, in pipeline order.
+var statsStages = []string{"ls-tree", "filter", "blame", "numstat", "estimate", "aggregate", "format"}
+
+type runStats struct {
+	Files           map[string]int     `json:"files"`
+	BlameSeconds    float64            `json:"blame_seconds"`
+	PeakConcurrency int                `json:"peak_concurrency"`
+	Stages          map[string]float64 `json:"stages"`
+	WallSeconds     float64            `json:"wall_seconds"`
+}
+
+// printStats summarizes the finished spans: stage wall times are summed over
+// repositories, blame time over the files.
+func printStats(t *tracer, spans []*span) {
+	stages := make(map[string]time.Duration)
+	var blame time.Duration
+	for _, s := range spans {
+		if s.name == "blame-file" {
+			blame += s.end.Sub(s.start)
+		} else {
+			stages[s.name] += s.end.Sub(s.start)
+		}
+	}
+
+	t.mu.Lock()
+	stats := runStats{
+		Files:           make(map[string]int),
+		BlameSeconds:    blame.Seconds(),
+		PeakConcurrency: t.peak["blame-file"],
+		Stages:          make(map[string]float64),
+		WallSeconds:     stages["gitfame"].Seconds(),
+	}
+	for _, name := range []string{"scanned", "filtered", "blamed", "reused", "skipped"} {
+		stats.Files[name] = t.counters[name]
+	}
+	t.mu.Unlock()
+
+	var walls []string
+	for _, name := range statsStages {
+		if d, ok := stages[name]; ok {
+			stats.Stages[name] = d.Seconds()
+			walls = append(walls, fmt.Sprintf("%s %s", name, d.Round(time.Millisecond)))
+		}
+	}
+
+	if t.stats == "json" {
+		_ = json.NewEncoder(os.Stderr).Encode(stats)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Files: %d scanned, %d filtered, %d blamed, %d reused, %d skipped\n",
+		stats.Files["scanned"], stats.Files["filtered"], stats.Files["blamed"], stats.Files["reused"], stats.Files["skipped"])
+	fmt.Fprintf(os.Stderr, "Blame time: %s, peak concurrency %d\n", blame.Round(time.Millisecond), stats.PeakConcurrency)
+	fmt.Fprintf(os.Stderr, "Stages: %s\n", strings.Join(walls, ", "))
+	fmt.Fprintf(os.Stderr, "Wall time: %s\n", stages["gitfame"].Round(time.Millisecond))
+}
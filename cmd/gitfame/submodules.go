@@ -0,0 +1,71 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+type submodule struct {
+	Path   string
+	Commit string
+}
+
+// listSubmodules returns the gitlink entries of the revision with the
+// commits they record.
+func listSubmodules(config Config) []submodule {
+	var submodules []submodule
+	for _, entry := range listTree(config) {
+		if entry.Mode == gitlinkMode {
+			submodules = append(submodules, submodule{Path: entry.Path, Commit: entry.Object})
+		}
+	}
+	return submodules
+}
+
+// addSubmoduleStats analyzes every checked out submodule at its recorded
+// commit, merges its actors into stats and appends its files with paths
+// prefixed by the submodule path.
+func addSubmoduleStats(stats map[string]ActorStats, files []FileStats, config Config) []FileStats {
+	if !config.RecurseSubmodules {
+		return files
+	}
+
+	for _, sm := range listSubmodules(config) {
+		sub := config
+		sub.Repository = filepath.Join(config.Repository, sm.Path)
+		sub.Revision = sm.Commit
+		// Derived columns are computed once over the merged stats.
+		sub.windows, sub.Survival, sub.Age, sub.DepthProfile = nil, false, false, false
+		sub.forge = nil
+
+		if !revisionExists(sub, sm.Commit) {
+			fmt.Fprintf(os.Stderr, "Warning: submodule %s is not checked out at %s, skipped\n", sm.Path, sm.Commit)
+			continue
+		}
+
+		report := collectStats(sub)
+		for actor, info := range report.Actors {
+			if existing, ok := stats[actor]; ok {
+				stats[actor] = mergeActorStats(existing, info)
+			} else {
+				stats[actor] = info
+			}
+		}
+		for _, file := range report.Files {
+			file.Path = path.Join(sm.Path, file.Path)
+			files = append(files, file)
+		}
+	}
+
+	for actor, s := range stats {
+		s.Commits = len(s.commitsSet)
+		stats[actor] = s
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
@@ -0,0 +1,37 @@
+//go:build !solution
+
+package main
+
+import "math"
+
+var survivalColumn = column{
+	Header:  "Survival%",
+	Key:     "survival",
+	Numeric: true,
+	Value: func(a ActorStats) any {
+		if a.added == 0 {
+			return 0.0
+		}
+		return math.Round(float64(a.Lines)/float64(a.added)*1000) / 10
+	},
+}
+
+// computeSurvival counts the lines every actor has ever added to files
+// matching the filters, including files deleted before the revision.
+func computeSurvival(stats map[string]ActorStats, config Config) {
+	if !config.Survival {
+		return
+	}
+
+	added := make(map[string]int)
+	forEachNumstat(config, func(e numstatEntry) {
+		if !e.Binary && matchesFilters(e.File, config) {
+			added[e.Actor] += e.Added
+		}
+	})
+
+	for actor, s := range stats {
+		s.added = added[actor]
+		stats[actor] = s
+	}
+}
@@ -0,0 +1,178 @@
+//go:build !solution && cgo
+
+package main
+
+import (
+	"context"
+	"slices"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/c"
+	"github.com/smacker/go-tree-sitter/cpp"
+	"github.com/smacker/go-tree-sitter/java"
+	"github.com/smacker/go-tree-sitter/javascript"
+	"github.com/smacker/go-tree-sitter/python"
+	"github.com/smacker/go-tree-sitter/ruby"
+	"github.com/smacker/go-tree-sitter/rust"
+	"github.com/smacker/go-tree-sitter/typescript/tsx"
+	"github.com/smacker/go-tree-sitter/typescript/typescript"
+)
+
+// symbolGrammar lists the node types of a tree-sitter grammar that declare
+// symbols. Classes are reported and qualify the names of the symbols inside
+// them, scopes like Rust impl blocks only qualify them.
+type symbolGrammar struct {
+	language  func() *sitter.Language
+	functions []string
+	classes   []string
+	scopes    []string
+}
+
+func (g symbolGrammar) kind(node *sitter.Node) string {
+	switch t := node.Type(); {
+	case slices.Contains(g.functions, t):
+		return "function"
+	case slices.Contains(g.classes, t):
+		return "class"
+	case slices.Contains(g.scopes, t):
+		return "scope"
+	}
+	return ""
+}
+
+var jsGrammar = symbolGrammar{
+	language:  javascript.GetLanguage,
+	functions: []string{"function_declaration", "generator_function_declaration", "method_definition", "variable_declarator"},
+	classes:   []string{"class_declaration"},
+}
+
+// symbolGrammars maps the languages of languageOf to their grammars.
+var symbolGrammars = map[string]symbolGrammar{
+	"python": {
+		language:  python.GetLanguage,
+		functions: []string{"function_definition"},
+		classes:   []string{"class_definition"},
+	},
+	"javascript": jsGrammar,
+	"typescript": {
+		language:  typescript.GetLanguage,
+		functions: jsGrammar.functions,
+		classes:   []string{"class_declaration", "abstract_class_declaration"},
+		scopes:    []string{"internal_module"},
+	},
+	"tsx": {
+		language:  tsx.GetLanguage,
+		functions: jsGrammar.functions,
+		classes:   []string{"class_declaration", "abstract_class_declaration"},
+	},
+	"java": {
+		language:  java.GetLanguage,
+		functions: []string{"method_declaration", "constructor_declaration"},
+		classes:   []string{"class_declaration", "interface_declaration", "enum_declaration", "record_declaration"},
+	},
+	"c": {
+		language:  c.GetLanguage,
+		functions: []string{"function_definition"},
+	},
+	"c++": {
+		language:  cpp.GetLanguage,
+		functions: []string{"function_definition"},
+		classes:   []string{"class_specifier", "struct_specifier"},
+		scopes:    []string{"namespace_definition"},
+	},
+	"rust": {
+		language:  rust.GetLanguage,
+		functions: []string{"function_item"},
+		classes:   []string{"struct_item", "enum_item", "trait_item"},
+		scopes:    []string{"impl_item", "mod_item"},
+	},
+	"ruby": {
+		language:  ruby.GetLanguage,
+		functions: []string{"method", "singleton_method"},
+		classes:   []string{"class", "module"},
+	},
+}
+
+func hasSymbolGrammar(lang string) bool {
+	_, ok := symbolGrammars[lang]
+	return ok
+}
+
+// parseSymbols lists the functions, methods and classes of a file in a
+// language with a tree-sitter grammar, named like Class.method.
+func parseSymbols(lang string, src []byte) []symbolSpan {
+	grammar, ok := symbolGrammars[lang]
+	if !ok {
+		return nil
+	}
+
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(grammar.language())
+	tree, err := parser.ParseCtx(context.Background(), nil, src)
+	if err != nil {
+		return nil
+	}
+	defer tree.Close()
+
+	var symbols []symbolSpan
+	var walk func(node *sitter.Node, prefix string)
+	walk = func(node *sitter.Node, prefix string) {
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			child := node.NamedChild(i)
+			kind, name := grammar.kind(child), ""
+			if kind != "" {
+				name = symbolName(child, src)
+			}
+			if name == "" {
+				walk(child, prefix)
+				continue
+			}
+			if kind != "scope" {
+				symbols = append(symbols, symbolSpan{
+					Name:  prefix + name,
+					Start: int(child.StartPoint().Row) + 1,
+					End:   int(child.EndPoint().Row) + 1,
+				})
+			}
+			walk(child, prefix+name+".")
+		}
+	}
+	walk(tree.RootNode(), "")
+	return symbols
+}
+
+// symbolName returns the name of a declaration node, or "" for nodes that
+// only look like one, such as variables that do not hold a function or
+// class forward declarations.
+func symbolName(node *sitter.Node, src []byte) string {
+	switch node.Type() {
+	case "variable_declarator":
+		value := node.ChildByFieldName("value")
+		if value == nil || value.Type() != "arrow_function" && value.Type() != "function_expression" && value.Type() != "function" {
+			return ""
+		}
+	case "class_specifier", "struct_specifier":
+		if node.ChildByFieldName("body") == nil {
+			return ""
+		}
+	case "impl_item":
+		if t := node.ChildByFieldName("type"); t != nil {
+			return t.Content(src)
+		}
+		return ""
+	case "function_definition":
+		// C and C++ name the function in nested declarators, like
+		// pointer_declarator > function_declarator > identifier.
+		if declarator := node.ChildByFieldName("declarator"); declarator != nil {
+			for declarator.ChildByFieldName("declarator") != nil {
+				declarator = declarator.ChildByFieldName("declarator")
+			}
+			return declarator.Content(src)
+		}
+	}
+	if name := node.ChildByFieldName("name"); name != nil {
+		return name.Content(src)
+	}
+	return ""
+}
@@ -0,0 +1,14 @@
+//go:build !solution && !cgo
+
+package main
+
+// The tree-sitter grammars are built with cgo; without it only Go files
+// have functions.
+
+func hasSymbolGrammar(lang string) bool {
+	return false
+}
+
+func parseSymbols(lang string, src []byte) []symbolSpan {
+	return nil
+}
@@ -0,0 +1,151 @@
+//go:build !solution
+
+package main
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// writeTabular lays the table out in columns separated by at least one
+// space, measuring cells by their width on a terminal, so that wide CJK
+// characters and combining marks keep the columns straight. With
+// --align-numbers numeric columns are right-aligned, and --max-column-width
+// cuts longer text cells with an ellipsis. Colors, if any, are applied to
+// the padded cells.
+func writeTabular(out io.Writer, t table, colors *tableColors, config Config) error {
+	rows := make([][]string, 0, len(t.Rows)+1)
+	rows = append(rows, t.Headers)
+	rows = append(rows, t.Rows...)
+
+	numeric := make([]bool, len(t.Headers))
+	for j := range numeric {
+		numeric[j] = config.AlignNumbers && numericColumn(t.Rows, j)
+	}
+	if config.MaxColumnWidth > 0 {
+		for i, row := range rows {
+			truncated := make([]string, len(row))
+			for j, cell := range row {
+				if j < len(numeric) && numeric[j] {
+					truncated[j] = cell
+				} else {
+					truncated[j] = truncateWidth(cell, config.MaxColumnWidth)
+				}
+			}
+			rows[i] = truncated
+		}
+	}
+
+	widths := make([]int, len(t.Headers))
+	for _, row := range rows {
+		for j, cell := range row {
+			if j < len(widths) {
+				widths[j] = max(widths[j], displayWidth(cell))
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i, row := range rows {
+		b.Reset()
+		for j, cell := range row {
+			last := j == len(row)-1
+			pad := 0
+			if j < len(widths) {
+				pad = widths[j] - displayWidth(cell)
+			}
+			code := colors.code(i-1, j)
+			if code != "" {
+				b.WriteString(code)
+			}
+			switch {
+			case j < len(numeric) && numeric[j]:
+				b.WriteString(strings.Repeat(" ", pad))
+				b.WriteString(cell)
+			case last:
+				b.WriteString(cell)
+			default:
+				b.WriteString(cell)
+				b.WriteString(strings.Repeat(" ", pad))
+			}
+			if code != "" {
+				b.WriteString(ansiReset)
+			}
+			if !last {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteByte('\n')
+		if _, err := io.WriteString(out, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// numericColumn reports whether every non-empty cell of the column is a
+// number, and at least one is.
+func numericColumn(rows [][]string, j int) bool {
+	found := false
+	for _, row := range rows {
+		if j >= len(row) || row[j] == "" {
+			continue
+		}
+		if _, err := strconv.ParseFloat(row[j], 64); err != nil {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// truncateWidth cuts s to at most width terminal columns, the last of them
+// an ellipsis.
+func truncateWidth(s string, width int) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	var b strings.Builder
+	w := 0
+	for _, r := range s {
+		rw := runeWidth(r)
+		if w+rw > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		w += rw
+	}
+	b.WriteRune('…')
+	return b.String()
+}
+
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return w
+}
+
+// runeWidth is 0 for combining marks and format characters, 2 for East
+// Asian wide and fullwidth characters and emoji, 1 otherwise.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0x1100 && r <= 0x115F,
+		r >= 0x2E80 && r <= 0xA4CF && r != 0x303F,
+		r >= 0xAC00 && r <= 0xD7A3,
+		r >= 0xF900 && r <= 0xFAFF,
+		r >= 0xFE30 && r <= 0xFE4F,
+		r >= 0xFF00 && r <= 0xFF60,
+		r >= 0xFFE0 && r <= 0xFFE6,
+		r >= 0x1F300 && r <= 0x1F64F,
+		r >= 0x1F900 && r <= 0x1F9FF,
+		r >= 0x20000 && r <= 0x3FFFD:
+		return 2
+	}
+	return 1
+}
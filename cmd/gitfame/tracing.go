@@ -0,0 +1,227 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tracesBatch is the number of spans sent in one export request.
+const tracesBatch = 1000
+
+// tracer records the spans of the analysis stages and file counters. It
+// exports the spans with OTLP/HTTP in JSON encoding to the endpoint, if any,
+// and prints the --stats summary. A nil tracer records nothing.
+type tracer struct {
+	endpoint string
+	stats    string
+	traceID  string
+	root     *span
+
+	mu       sync.Mutex
+	spans    []*span
+	active   map[string]int
+	peak     map[string]int
+	counters map[string]int
+	done     bool
+}
+
+type span struct {
+	tracer *tracer
+	id     string
+	parent string
+	name   string
+	start  time.Time
+	end    time.Time
+	attrs  map[string]string
+}
+
+func newTracer(endpoint, stats string) *tracer {
+	if endpoint != "" && !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint = strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	}
+	t := &tracer{
+		endpoint: endpoint,
+		stats:    stats,
+		traceID:  randomID(16),
+		active:   make(map[string]int),
+		peak:     make(map[string]int),
+		counters: make(map[string]int),
+	}
+	t.root = t.start("gitfame", nil)
+	return t
+}
+
+func randomID(n int) string {
+	id := make([]byte, n)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// start begins a span; a nil parent makes it a child of the root span.
+func (t *tracer) start(name string, parent *span, attrs ...string) *span {
+	if t == nil {
+		return nil
+	}
+	s := &span{tracer: t, id: randomID(8), name: name, start: time.Now(), attrs: make(map[string]string)}
+	if parent == nil {
+		parent = t.root
+	}
+	if parent != nil {
+		s.parent = parent.id
+	}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		s.attrs[attrs[i]] = attrs[i+1]
+	}
+
+	t.mu.Lock()
+	t.active[name]++
+	t.peak[name] = max(t.peak[name], t.active[name])
+	t.mu.Unlock()
+	return s
+}
+
+// count adds n to a counter of the --stats summary.
+func (t *tracer) count(name string, n int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.counters[name] += n
+	t.mu.Unlock()
+}
+
+func (s *span) set(key, value string) {
+	if s != nil {
+		s.attrs[key] = value
+	}
+}
+
+func (s *span) finish() {
+	if s == nil {
+		return
+	}
+	s.end = time.Now()
+	s.tracer.mu.Lock()
+	s.tracer.spans = append(s.tracer.spans, s)
+	s.tracer.active[s.name]--
+	s.tracer.mu.Unlock()
+}
+
+// flush ends the root span, prints the --stats summary and exports all the
+// spans. Later calls do nothing; export errors are only reported.
+func (t *tracer) flush() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	done := t.done
+	t.done = true
+	t.mu.Unlock()
+	if done {
+		return
+	}
+	t.root.finish()
+
+	t.mu.Lock()
+	spans := t.spans
+	t.mu.Unlock()
+	if t.stats != "" {
+		printStats(t, spans)
+	}
+	if t.endpoint == "" {
+		return
+	}
+	for len(spans) > 0 {
+		batch := spans[:min(len(spans), tracesBatch)]
+		spans = spans[len(batch):]
+		if err := t.export(batch); err != nil {
+			fmt.Fprintf(os.Stderr, "Tracing export error: %v\n", err)
+			return
+		}
+	}
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID      string          `json:"traceId"`
+	SpanID       string          `json:"spanId"`
+	ParentSpanID string          `json:"parentSpanId,omitempty"`
+	Name         string          `json:"name"`
+	Kind         int             `json:"kind"`
+	Start        string          `json:"startTimeUnixNano"`
+	End          string          `json:"endTimeUnixNano"`
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+}
+
+func otlpAttributes(attrs map[string]string) []otlpAttribute {
+	result := make([]otlpAttribute, 0, len(attrs))
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		a := otlpAttribute{Key: key}
+		a.Value.StringValue = attrs[key]
+		result = append(result, a)
+	}
+	return result
+}
+
+func (t *tracer) export(spans []*span) error {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		encoded = append(encoded, otlpSpan{
+			TraceID:      t.traceID,
+			SpanID:       s.id,
+			ParentSpanID: s.parent,
+			Name:         s.name,
+			// SPAN_KIND_INTERNAL
+			Kind:       1,
+			Start:      strconv.FormatInt(s.start.UnixNano(), 10),
+			End:        strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes: otlpAttributes(s.attrs),
+		})
+	}
+
+	payload := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource":   map[string]any{"attributes": otlpAttributes(map[string]string{"service.name": "gitfame"})},
+			"scopeSpans": []any{map[string]any{"scope": map[string]string{"name": "gitfame"}, "spans": encoded}},
+		}},
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(t.endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("POST %s: %s", t.endpoint, resp.Status)
+	}
+	return nil
+}
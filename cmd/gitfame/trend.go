@@ -0,0 +1,52 @@
+//go:build !solution
+
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// sparkBlocks are the bar heights of a sparkline, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+var trendColumn = column{Header: "Trend", Key: "trend", Value: func(a ActorStats) any { return sparkline(a.trend) }}
+
+// computeTrend counts the surviving lines each actor authored in each of the
+// last --sparkline periods before the revision date, oldest first.
+func computeTrend(stats map[string]ActorStats, config Config) {
+	if config.Sparkline == 0 {
+		return
+	}
+
+	revisionTime := commitTime(config, config.Revision)
+	period := int64(config.sparklinePeriod.Duration / time.Second)
+	for actor, s := range stats {
+		s.trend = make([]int, config.Sparkline)
+		for t, n := range s.authorTimes {
+			// Lines dated after the revision are left out.
+			back := (revisionTime - t) / period
+			if revisionTime-t >= 0 && back < int64(config.Sparkline) {
+				s.trend[config.Sparkline-1-int(back)] += n
+			}
+		}
+		stats[actor] = s
+	}
+}
+
+// sparkline scales the counts to the highest of them.
+func sparkline(counts []int) string {
+	peak := 0
+	for _, n := range counts {
+		peak = max(peak, n)
+	}
+	var b strings.Builder
+	for _, n := range counts {
+		level := 0
+		if peak > 0 {
+			level = n * (len(sparkBlocks) - 1) / peak
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
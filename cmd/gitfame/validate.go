@@ -0,0 +1,181 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// validateOutput checks rendered JSON/CSV output against the active columns and
+// the stats it was rendered from. Tabular output is meant for humans and is
+// only checked for row-level consistency.
+func validateOutput(data []byte, actors []ActorStats, columns []column, config Config) error {
+	if err := validateActors(actors, config); err != nil {
+		return err
+	}
+
+	switch config.Format {
+	case "csv":
+		return validateCSV(data, actors, columns)
+	case "json":
+		return validateJSON(data, actors, columns)
+	case "json-lines":
+		return validateJSONLines(data, actors, columns)
+	}
+	return nil
+}
+
+func validateActors(actors []ActorStats, config Config) error {
+	seen := make(map[string]struct{}, len(actors))
+	for _, actor := range actors {
+		if actor.Name == "" {
+			return fmt.Errorf("actor with empty name")
+		}
+		if _, ok := seen[actor.Name]; ok {
+			return fmt.Errorf("duplicate actor %q", actor.Name)
+		}
+		seen[actor.Name] = struct{}{}
+
+		if actor.Lines < 0 || actor.Commits < 0 || actor.Files < 0 {
+			return fmt.Errorf("negative counters for actor %q", actor.Name)
+		}
+		if actor.commitsSet != nil && actor.Commits != len(actor.commitsSet) {
+			return fmt.Errorf("actor %q has %d commits, but %d distinct commit hashes",
+				actor.Name, actor.Commits, len(actor.commitsSet))
+		}
+		if config.Mode != "fast" && actor.Files == 0 {
+			return fmt.Errorf("actor %q is not attributed to any file", actor.Name)
+		}
+	}
+	return nil
+}
+
+func validateCSV(data []byte, actors []ActorStats, columns []column) error {
+	// Additional sections follow the main table after a blank line.
+	data, _, _ = bytes.Cut(data, []byte("\n\n"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("malformed csv: %w", err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("csv output has no header")
+	}
+
+	headers := columnHeaders(columns)
+	if len(records[0]) != len(headers) {
+		return fmt.Errorf("csv header has %d columns, expected %d", len(records[0]), len(headers))
+	}
+	for i, header := range headers {
+		if records[0][i] != header {
+			return fmt.Errorf("csv column %d is %q, expected %q", i, records[0][i], header)
+		}
+	}
+
+	rows := records[1:]
+	if len(rows) != len(actors) {
+		return fmt.Errorf("csv has %d rows, expected %d", len(rows), len(actors))
+	}
+	for i, row := range rows {
+		expected := columnValues(columns, actors[i])
+		for j, c := range columns {
+			if c.Numeric {
+				if _, err := strconv.ParseFloat(row[j], 64); err != nil {
+					return fmt.Errorf("csv row %d: column %s is not a number: %q", i+1, c.Header, row[j])
+				}
+			}
+			if row[j] != expected[j] {
+				return fmt.Errorf("csv row %d: column %s is %q, expected %q", i+1, c.Header, row[j], expected[j])
+			}
+		}
+	}
+	return nil
+}
+
+func validateJSON(data []byte, actors []ActorStats, columns []column) error {
+	var rows []map[string]any
+	if err := json.Unmarshal(data, &rows); err != nil {
+		var envelope struct {
+			Authors []map[string]any `json:"authors"`
+		}
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return fmt.Errorf("malformed json: %w", err)
+		}
+		if envelope.Authors == nil {
+			return fmt.Errorf("json envelope without authors")
+		}
+		rows = envelope.Authors
+	}
+	return validateRows(rows, actors, columns)
+}
+
+func validateJSONLines(data []byte, actors []ActorStats, columns []column) error {
+	var rows []map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var row map[string]any
+		if err := decoder.Decode(&row); err != nil {
+			return fmt.Errorf("malformed json line: %w", err)
+		}
+		if _, ok := row["metadata"]; ok && len(row) == 1 {
+			continue
+		}
+		if _, ok := row["section"]; ok {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return validateRows(rows, actors, columns)
+}
+
+func validateRows(rows []map[string]any, actors []ActorStats, columns []column) error {
+	if len(rows) != len(actors) {
+		return fmt.Errorf("json has %d rows, expected %d", len(rows), len(actors))
+	}
+	for i, row := range rows {
+		// --show-files nests the files of the actor.
+		delete(row, "owned_files")
+		if len(row) != len(columns) {
+			return fmt.Errorf("json row %d has %d fields, expected %d", i+1, len(row), len(columns))
+		}
+		expected := columnValues(columns, actors[i])
+		for j, c := range columns {
+			value, ok := row[c.Key]
+			if !ok {
+				return fmt.Errorf("json row %d: missing field %q", i+1, c.Key)
+			}
+
+			var actual string
+			switch v := value.(type) {
+			case string:
+				if c.Numeric {
+					return fmt.Errorf("json row %d: field %q must be a number", i+1, c.Key)
+				}
+				actual = v
+			case float64:
+				if !c.Numeric {
+					return fmt.Errorf("json row %d: field %q must be a string", i+1, c.Key)
+				}
+				actual = strconv.FormatFloat(v, 'f', -1, 64)
+			case []any:
+				names := make([]string, 0, len(v))
+				for _, name := range v {
+					names = append(names, fmt.Sprint(name))
+				}
+				actual = formatValue(names)
+			default:
+				return fmt.Errorf("json row %d: field %q has unexpected type %T", i+1, c.Key, value)
+			}
+			if actual != expected[j] {
+				return fmt.Errorf("json row %d: field %q is %q, expected %q", i+1, c.Key, actual, expected[j])
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+//go:build !solution
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type window struct {
+	Label    string
+	Duration time.Duration
+}
+
+var windowUnits = map[byte]time.Duration{
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+	'y': 365 * 24 * time.Hour,
+}
+
+// parseWindow parses window lengths like 30d, 2w or 1y.
+func parseWindow(s string) (window, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return window{}, fmt.Errorf("invalid window %q", s)
+	}
+
+	unit, ok := windowUnits[s[len(s)-1]]
+	if !ok {
+		return window{}, fmt.Errorf("invalid window unit in %q", s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return window{}, fmt.Errorf("invalid window length in %q", s)
+	}
+
+	return window{Label: s, Duration: time.Duration(n) * unit}, nil
+}
+
+func velocityColumns(config Config) []column {
+	var columns []column
+	for i, w := range config.windows {
+		columns = append(columns, column{
+			Header:  "Velocity" + w.Label,
+			Key:     "velocity_" + w.Label,
+			Numeric: true,
+			Value: func(a ActorStats) any {
+				if i < len(a.velocity) {
+					return a.velocity[i]
+				}
+				return 0
+			},
+		})
+	}
+	return columns
+}
+
+// computeVelocity counts, for every window, the surviving lines each actor
+// authored within that window before the revision date.
+func computeVelocity(stats map[string]ActorStats, config Config) {
+	if len(config.windows) == 0 {
+		return
+	}
+
+	revisionTime := commitTime(config, config.Revision)
+	for actor, s := range stats {
+		s.velocity = make([]int, len(config.windows))
+		for t, n := range s.authorTimes {
+			age := time.Duration(revisionTime-t) * time.Second
+			for i, w := range config.windows {
+				if age <= w.Duration {
+					s.velocity[i] += n
+				}
+			}
+		}
+		stats[actor] = s
+	}
+}
+
+func commitTime(config Config, revision string) int64 {
+	cmd := gitCommand(config, "log", "-1", "--format=%ct", revision)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка выполнения команды git log: %v\n", err)
+		return 0
+	}
+
+	t, err := strconv.ParseInt(strings.TrimSpace(out.String()), 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка преобразования: %v\n", err)
+		return 0
+	}
+	return t
+}
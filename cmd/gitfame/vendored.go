@@ -0,0 +1,33 @@
+//go:build !solution
+
+package main
+
+import "strings"
+
+// defaultExcludedDirs are skipped anywhere in the tree unless
+// --no-default-excludes is set.
+var defaultExcludedDirs = map[string]bool{
+	"vendor":       true,
+	"node_modules": true,
+	"third_party":  true,
+	"dist":         true,
+}
+
+// excludedByDefault reports whether the file lies in a well-known vendored
+// directory. A linguist-vendored=false attribute keeps it.
+func excludedByDefault(file string, config Config) bool {
+	if config.NoDefaultExcludes {
+		return false
+	}
+	if value := fileAttributes(file, config)["linguist-vendored"]; value == "unset" || value == "false" {
+		return false
+	}
+
+	dirs := strings.Split(file, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if defaultExcludedDirs[dir] {
+			return true
+		}
+	}
+	return false
+}
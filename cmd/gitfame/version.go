@@ -0,0 +1,92 @@
+//go:build !solution
+
+package main
+
+import (
+	"io"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Build metadata, set with
+// -ldflags "-X main.version=v1.2.0 -X main.commit=... -X main.buildDate=...".
+var (
+	version   = ""
+	commit    = ""
+	buildDate = ""
+)
+
+func newVersionCmd(config *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Prints the version of gitfame and of the git binary it runs",
+		Args:  cobra.NoArgs,
+		// Works outside of a repository.
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			rejectReportFlags(cmd)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				return writeTable(out, versionTable(config), nil, config)
+			})
+		},
+	}
+}
+
+// buildVersion returns the version, commit and build date from the ldflags,
+// falling back to the module and VCS data embedded by go build.
+func buildVersion() (string, string, string) {
+	v, c, d := version, commit, buildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v == "" && info.Main.Version != "(devel)" {
+			v = info.Main.Version
+		}
+		vcs := make(map[string]string)
+		for _, s := range info.Settings {
+			vcs[s.Key] = s.Value
+		}
+		if c == "" && vcs["vcs.revision"] != "" {
+			c = vcs["vcs.revision"]
+			if vcs["vcs.modified"] == "true" {
+				c += "-dirty"
+			}
+		}
+		if d == "" {
+			d = vcs["vcs.time"]
+		}
+	}
+	if v == "" {
+		v = "dev"
+	}
+	return v, c, d
+}
+
+func gitVersion(config Config) string {
+	cmd := exec.Command("git", "version")
+	cmd.Env = gitEnv(config.UseUserGitConfig)
+	out, err := cmd.Output()
+	if err != nil {
+		return "not found"
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(out)), "git version ")
+}
+
+func versionTable(config Config) table {
+	v, c, d := buildVersion()
+	git := gitVersion(config)
+	return table{
+		Headers: []string{"Version", "Commit", "BuildDate", "Go", "Git"},
+		Rows:    [][]string{{v, c, d, runtime.Version(), git}},
+		Items: []record{{
+			{Key: "version", Value: v},
+			{Key: "commit", Value: c},
+			{Key: "build_date", Value: d},
+			{Key: "go", Value: runtime.Version()},
+			{Key: "git", Value: git},
+		}},
+	}
+}
@@ -0,0 +1,136 @@
+//go:build !solution
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func newWhatIfCmd(config *Config) *cobra.Command {
+	var remove []string
+
+	cmd := &cobra.Command{
+		Use:   "what-if",
+		Short: "Shows which files and directories lose their majority owner if authors leave",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			report := collectStats(*config)
+
+			removed := make(map[string]bool, len(remove))
+			for _, id := range remove {
+				actor, ok := resolveActor(report.Actors, id)
+				if !ok {
+					fmt.Fprintf(os.Stderr, "Unknown author: %s\n", id)
+					os.Exit(2)
+				}
+				removed[anonymizedName(actor, *config)] = true
+			}
+			if config.Anonymize {
+				report = anonymizeReport(report, *config)
+			}
+
+			main, summary := whatIfTables(report.Files, removed, *config)
+			writeCommandOutput(*config, func(out io.Writer, config Config) error {
+				return writeSections(out, main, []section{summary}, report.Metadata, config)
+			})
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&remove, "remove", nil, "Names or emails of the authors who leave")
+	_ = cmd.MarkFlagRequired("remove")
+
+	return cmd
+}
+
+// whatIfTables lists the directories and files whose majority owner (more
+// than half of the lines) is one of the removed actors, with the share of
+// lines that would be left without an active author. The summary adds up the
+// orphaned lines of the whole repository.
+func whatIfTables(files []FileStats, removed map[string]bool, config Config) (table, section) {
+	type row struct {
+		path, kind, owner string
+		share             float64
+		lines, orphaned   int
+	}
+
+	var rows []row
+	add := func(path, kind string, lines map[string]int) {
+		owner, total, share := topOwner(lines)
+		if total == 0 || !removed[owner] || share <= 50 {
+			return
+		}
+		orphaned := 0
+		for actor, n := range lines {
+			if removed[actor] {
+				orphaned += n
+			}
+		}
+		rows = append(rows, row{path: path, kind: kind, owner: owner, share: share, lines: total, orphaned: orphaned})
+	}
+
+	dirs := directoryLines(files)
+	for dir, lines := range dirs {
+		add(dir, "directory", lines)
+	}
+	for _, file := range files {
+		add(formatPath(file.Path, config), "file", file.Lines)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].kind != rows[j].kind {
+			return rows[i].kind == "directory"
+		}
+		return rows[i].path < rows[j].path
+	})
+
+	t := table{
+		Headers: []string{"Path", "Type", "Owner", "Share%", "Lines", "Orphaned%"},
+		Rows:    make([][]string, 0, len(rows)),
+		Items:   make([]record, 0, len(rows)),
+	}
+	filesLost := 0
+	for _, r := range rows {
+		if r.kind == "file" {
+			filesLost++
+		}
+		orphaned := percent(r.orphaned, r.lines)
+		t.Rows = append(t.Rows, []string{r.path, r.kind, r.owner, formatValue(r.share), strconv.Itoa(r.lines), formatValue(orphaned)})
+		t.Items = append(t.Items, record{
+			{Key: "path", Value: r.path},
+			{Key: "type", Value: r.kind},
+			{Key: "owner", Value: r.owner},
+			{Key: "share", Value: r.share},
+			{Key: "lines", Value: r.lines},
+			{Key: "orphaned", Value: orphaned},
+		})
+	}
+
+	orphaned, total := 0, 0
+	for actor, n := range dirs["."] {
+		total += n
+		if removed[actor] {
+			orphaned += n
+		}
+	}
+	share := percent(orphaned, total)
+	summary := section{
+		Name:  "summary",
+		Title: "Orphaned code",
+		Table: table{
+			Headers: []string{"OrphanedLines", "Share%", "FilesWithoutOwner", "DirectoriesWithoutOwner"},
+			Rows:    [][]string{{strconv.Itoa(orphaned), formatValue(share), strconv.Itoa(filesLost), strconv.Itoa(len(rows) - filesLost)}},
+			Items: []record{{
+				{Key: "orphaned_lines", Value: orphaned},
+				{Key: "share", Value: share},
+				{Key: "files_without_owner", Value: filesLost},
+				{Key: "directories_without_owner", Value: len(rows) - filesLost},
+			}},
+		},
+	}
+	return t, summary
+}
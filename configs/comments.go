@@ -0,0 +1,107 @@
+package configs
+
+// CommentSyntax lists the tokens that start a comment running to the end of
+// the line and the start and end tokens of block comments.
+type CommentSyntax struct {
+	Line  []string
+	Block [][2]string
+}
+
+var (
+	cStyle    = CommentSyntax{Line: []string{"//"}, Block: [][2]string{{"/*", "*/"}}}
+	hashStyle = CommentSyntax{Line: []string{"#"}}
+	dashStyle = CommentSyntax{Line: []string{"--"}}
+	semiStyle = CommentSyntax{Line: []string{";"}}
+	xmlStyle  = CommentSyntax{Block: [][2]string{{"<!--", "-->"}}}
+)
+
+// commentSyntax is keyed by the lower-cased language names of
+// language_extensions.json.
+var commentSyntax = map[string]CommentSyntax{
+	"c":               cStyle,
+	"c++":             cStyle,
+	"c#":              cStyle,
+	"cuda":            cStyle,
+	"objective-c":     cStyle,
+	"go":              cStyle,
+	"java":            cStyle,
+	"javascript":      cStyle,
+	"typescript":      cStyle,
+	"jsx":             cStyle,
+	"kotlin":          cStyle,
+	"scala":           cStyle,
+	"groovy":          cStyle,
+	"gradle":          cStyle,
+	"swift":           cStyle,
+	"rust":            cStyle,
+	"dart":            cStyle,
+	"protocol buffer": cStyle,
+	"thrift":          cStyle,
+	"glsl":            cStyle,
+	"hlsl":            cStyle,
+	"json5":           cStyle,
+	"less":            cStyle,
+	"scss":            cStyle,
+	"stylus":          cStyle,
+	"css":             {Block: [][2]string{{"/*", "*/"}}},
+	"php":             {Line: []string{"//", "#"}, Block: [][2]string{{"/*", "*/"}}},
+	"python":          hashStyle,
+	"cython":          hashStyle,
+	"ruby":            {Line: []string{"#"}, Block: [][2]string{{"=begin", "=end"}}},
+	"perl":            hashStyle,
+	"shell":           hashStyle,
+	"fish":            hashStyle,
+	"tcsh":            hashStyle,
+	"powershell":      {Line: []string{"#"}, Block: [][2]string{{"<#", "#>"}}},
+	"makefile":        hashStyle,
+	"cmake":           hashStyle,
+	"dockerfile":      hashStyle,
+	"yaml":            hashStyle,
+	"toml":            hashStyle,
+	"r":               hashStyle,
+	"julia":           {Line: []string{"#"}, Block: [][2]string{{"#=", "=#"}}},
+	"elixir":          hashStyle,
+	"nix":             {Line: []string{"#"}, Block: [][2]string{{"/*", "*/"}}},
+	"hcl":             {Line: []string{"#", "//"}, Block: [][2]string{{"/*", "*/"}}},
+	"graphql":         hashStyle,
+	"nginx":           hashStyle,
+	"apacheconf":      hashStyle,
+	"ini":             {Line: []string{";", "#"}},
+	"sql":             {Line: []string{"--"}, Block: [][2]string{{"/*", "*/"}}},
+	"plsql":           {Line: []string{"--"}, Block: [][2]string{{"/*", "*/"}}},
+	"plpgsql":         {Line: []string{"--"}, Block: [][2]string{{"/*", "*/"}}},
+	"lua":             {Line: []string{"--"}, Block: [][2]string{{"--[[", "]]"}}},
+	"haskell":         {Line: []string{"--"}, Block: [][2]string{{"{-", "-}"}}},
+	"elm":             {Line: []string{"--"}, Block: [][2]string{{"{-", "-}"}}},
+	"ada":             dashStyle,
+	"vhdl":            dashStyle,
+	"ocaml":           {Block: [][2]string{{"(*", "*)"}}},
+	"f#":              {Line: []string{"//"}, Block: [][2]string{{"(*", "*)"}}},
+	"erlang":          {Line: []string{"%"}},
+	"matlab":          {Line: []string{"%"}, Block: [][2]string{{"%{", "%}"}}},
+	"tex":             {Line: []string{"%"}},
+	"clojure":         semiStyle,
+	"common lisp":     {Line: []string{";"}, Block: [][2]string{{"#|", "|#"}}},
+	"emacs lisp":      semiStyle,
+	"scheme":          semiStyle,
+	"racket":          semiStyle,
+	"assembly":        semiStyle,
+	"viml":            {Line: []string{"\""}},
+	"visual basic":    {Line: []string{"'"}},
+	"batchfile":       {Line: []string{"REM ", "rem ", "::"}},
+	"html":            xmlStyle,
+	"xml":             xmlStyle,
+	"svg":             xmlStyle,
+	"markdown":        xmlStyle,
+	"vue":             {Line: []string{"//"}, Block: [][2]string{{"<!--", "-->"}, {"/*", "*/"}}},
+}
+
+// LoadCommentSyntax returns the comment syntax of the languages that have
+// comments, by lower-cased language name.
+func LoadCommentSyntax() map[string]CommentSyntax {
+	syntax := make(map[string]CommentSyntax, len(commentSyntax))
+	for lang, s := range commentSyntax {
+		syntax[lang] = s
+	}
+	return syntax
+}
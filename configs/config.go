@@ -15,9 +15,34 @@ type languageExtension struct {
 	Name       string   `json:"name"`
 	Type       string   `json:"type"`
 	Extensions []string `json:"extensions"`
+	Filenames  []string `json:"filenames"`
 }
 
 func LoadExtensionsMap() map[string][]string {
+	extensionsMap := make(map[string][]string)
+	for _, lang := range loadLanguages() {
+		for _, ext := range lang.Extensions {
+			langName := strings.ToLower(lang.Name)
+			extensionsMap[langName] = append(extensionsMap[langName], ext)
+		}
+	}
+	return extensionsMap
+}
+
+// LoadFilenamesMap returns the base name patterns of languages that are
+// recognized by file name, like Dockerfile or Makefile.
+func LoadFilenamesMap() map[string][]string {
+	filenamesMap := make(map[string][]string)
+	for _, lang := range loadLanguages() {
+		for _, name := range lang.Filenames {
+			langName := strings.ToLower(lang.Name)
+			filenamesMap[langName] = append(filenamesMap[langName], name)
+		}
+	}
+	return filenamesMap
+}
+
+func loadLanguages() []languageExtension {
 	var languages []languageExtension
 	// Изменяем способ чтения файла с использованием go:embed
 	data, err := languageExtensionJSON.ReadFile("language_extensions.json")
@@ -31,13 +56,36 @@ func LoadExtensionsMap() map[string][]string {
 		fmt.Fprintf(os.Stderr, "Ошибка при декодировании JSON: %v\n", err)
 		os.Exit(1)
 	}
+	return languages
+}
+
+// MergeLanguagesFile adds the languages of a user-provided file in the
+// language_extensions.json format to the maps. A language that is already
+// known gets its extensions and file names replaced.
+func MergeLanguagesFile(path string, extensionsMap, filenamesMap map[string][]string) error {
+	var languages []languageExtension
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &languages); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
 
-	extensionsMap := make(map[string][]string)
 	for _, lang := range languages {
-		for _, ext := range lang.Extensions {
-			langName := strings.ToLower(lang.Name)
-			extensionsMap[langName] = append(extensionsMap[langName], ext)
+		langName := strings.ToLower(lang.Name)
+		if langName == "" {
+			return fmt.Errorf("%s: language without a name", path)
+		}
+		delete(extensionsMap, langName)
+		delete(filenamesMap, langName)
+		if len(lang.Extensions) > 0 {
+			extensionsMap[langName] = lang.Extensions
+		}
+		if len(lang.Filenames) > 0 {
+			filenamesMap[langName] = lang.Filenames
 		}
 	}
-	return extensionsMap
+	return nil
 }
@@ -0,0 +1,100 @@
+package configs
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// File is a user-provided gitfame configuration file.
+type File struct {
+	// Defaults maps flag names to values used when the flag is not given.
+	Defaults map[string]any `yaml:"defaults"`
+	// Profiles are named sets of flag values selected with --profile; they
+	// take precedence over Defaults.
+	Profiles     map[string]map[string]any   `yaml:"profiles"`
+	Languages    map[string]LanguageOverride `yaml:"languages"`
+	AccessReview []PathSet                   `yaml:"access_review"`
+	// Owners maps author names or emails to CODEOWNERS owners, like @alice
+	// or @org/team.
+	Owners map[string]string `yaml:"owners"`
+	// Redaction maps an output sink (a format or "access-review") to the
+	// columns it must not reveal.
+	Redaction map[string]RedactionPolicy `yaml:"redaction"`
+}
+
+// RedactionPolicy lists column keys to remove or to replace with a hash.
+type RedactionPolicy struct {
+	Drop []string `yaml:"drop"`
+	Mask []string `yaml:"mask"`
+}
+
+// LanguageOverride holds filters that apply only while the language is
+// selected with --languages.
+type LanguageOverride struct {
+	Exclude []string `yaml:"exclude"`
+}
+
+// PathSet is a named group of sensitive paths for access reviews.
+type PathSet struct {
+	Name  string   `yaml:"name"`
+	Paths []string `yaml:"paths"`
+}
+
+// MergeFiles returns base with the settings of override on top: defaults,
+// profiles, languages, redaction policies and owners are merged by key,
+// access review path sets are replaced.
+func MergeFiles(base, override File) File {
+	merged := File{
+		Defaults:     make(map[string]any, len(base.Defaults)+len(override.Defaults)),
+		Profiles:     make(map[string]map[string]any, len(base.Profiles)+len(override.Profiles)),
+		Languages:    make(map[string]LanguageOverride, len(base.Languages)+len(override.Languages)),
+		AccessReview: base.AccessReview,
+		Redaction:    make(map[string]RedactionPolicy, len(base.Redaction)+len(override.Redaction)),
+		Owners:       make(map[string]string, len(base.Owners)+len(override.Owners)),
+	}
+	for _, f := range []File{base, override} {
+		for k, v := range f.Defaults {
+			merged.Defaults[k] = v
+		}
+		for k, v := range f.Profiles {
+			merged.Profiles[k] = v
+		}
+		for k, v := range f.Languages {
+			merged.Languages[k] = v
+		}
+		for k, v := range f.Redaction {
+			merged.Redaction[k] = v
+		}
+		for k, v := range f.Owners {
+			merged.Owners[k] = v
+		}
+	}
+	if len(override.AccessReview) > 0 {
+		merged.AccessReview = override.AccessReview
+	}
+	return merged
+}
+
+func LoadFile(path string) (File, error) {
+	var file File
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, err
+	}
+
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return file, fmt.Errorf("%s: %w", path, err)
+	}
+
+	languages := make(map[string]LanguageOverride, len(file.Languages))
+	for name, override := range file.Languages {
+		languages[strings.ToLower(name)] = override
+	}
+	file.Languages = languages
+
+	return file, nil
+}
@@ -0,0 +1,28 @@
+package configs
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// LoadListFile reads one entry, like a glob or a repository, per line,
+// skipping blank lines and lines starting with '#'.
+func LoadListFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	return entries, scanner.Err()
+}
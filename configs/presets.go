@@ -0,0 +1,39 @@
+package configs
+
+// presets are curated exclude globs for common ecosystems.
+var presets = map[string][]string{
+	"node": {
+		"**/package-lock.json",
+		"**/yarn.lock",
+		"**/pnpm-lock.yaml",
+		"**/node_modules/**",
+		"**/dist/**", "**/build/**", "**/coverage/**",
+		"**/*.min.js", "**/*.min.css", "**/*.map",
+	},
+	"go": {
+		"**/go.sum",
+		"**/vendor/**",
+		"**/*.pb.go", "**/*_mock.go", "**/mock_*.go", "**/mocks/**",
+		"**/zz_generated*.go",
+	},
+	"python": {
+		"**/poetry.lock",
+		"**/Pipfile.lock",
+		"**/__pycache__/**", "**/*.pyc",
+		"**/.venv/**", "**/venv/**",
+		"**/*.egg-info/**", "**/dist/**", "**/build/**",
+		"**/*_pb2.py", "**/*_pb2_grpc.py",
+	},
+	"monorepo-ci": {
+		".github/**", "**/.gitlab-ci.yml",
+		".circleci/**", "**/Jenkinsfile",
+		"**/generated/**", "**/gen/**",
+		"**/*.snap", "**/__snapshots__/**",
+	},
+}
+
+// PresetExcludes returns the exclude globs of a preset.
+func PresetExcludes(name string) ([]string, bool) {
+	patterns, ok := presets[name]
+	return patterns, ok
+}
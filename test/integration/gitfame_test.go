@@ -50,19 +50,34 @@ func TestGitFame(t *testing.T) {
 			args = append(args, tc.Args...)
 
 			Unbundle(t, filepath.Join(bundlesDir, tc.Bundle), dir)
+			for _, command := range tc.Setup {
+				RunCommand(t, binary, dir, command)
+			}
 			headRef := GetHEADRef(t, dir)
 
-			cmd := exec.Command(binary, args...)
-			cmd.Stderr = os.Stderr
-
-			output, err := cmd.Output()
-			if !tc.Error {
-				require.NoError(t, err)
-				CompareResults(t, tc.Expected, output, tc.Format)
-			} else {
-				require.Error(t, err)
-				_, ok := err.(*exec.ExitError)
-				require.True(t, ok)
+			// Runs after the first one see the files the previous ones left,
+			// like state files, and must print the same output.
+			for run := 0; run < max(tc.Runs, 1); run++ {
+				cmd := exec.Command(binary, args...)
+				cmd.Dir = dir
+				cmd.Stderr = os.Stderr
+
+				output, err := cmd.Output()
+				for _, command := range tc.Check {
+					output = append(output, RunCommand(t, binary, dir, command)...)
+				}
+				if !tc.Error {
+					require.NoError(t, err)
+					CompareResults(t, tc.Expected, output, tc.Format)
+				} else {
+					require.Error(t, err)
+					_, ok := err.(*exec.ExitError)
+					require.True(t, ok)
+					// Failed policies still print the report.
+					if len(tc.Expected) > 0 {
+						CompareResults(t, tc.Expected, output, tc.Format)
+					}
+				}
 			}
 
 			newHEADRef := GetHEADRef(t, dir)
@@ -120,6 +135,12 @@ type TestDescription struct {
 	Bundle string   `yaml:"bundle"`
 	Error  bool     `yaml:"error"`
 	Format string   `yaml:"format,omitempty"`
+	Runs   int      `yaml:"runs,omitempty"`
+	// Setup commands run in the repository before the test and Check
+	// commands after every run, appending their output to that of gitfame;
+	// "gitfame" runs the binary under test.
+	Setup [][]string `yaml:"setup,omitempty"`
+	Check [][]string `yaml:"check,omitempty"`
 }
 
 func ReadTestDescription(t *testing.T, path string) *TestDescription {
@@ -141,6 +162,22 @@ func Unbundle(t *testing.T, src, dst string) {
 	require.NoError(t, cmd.Run())
 }
 
+func RunCommand(t *testing.T, binary, dir string, command []string) []byte {
+	t.Helper()
+
+	name := command[0]
+	if name == "gitfame" {
+		name = binary
+	}
+	cmd := exec.Command(name, command[1:]...)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	return out
+}
+
 func CompareResults(t *testing.T, expected, actual []byte, format string) {
 	t.Helper()
 